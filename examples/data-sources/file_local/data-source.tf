@@ -3,3 +3,16 @@
 data "file_local" "basic_example" {
   name = "example.txt"
 }
+
+# tflint-ignore: terraform_unused_declarations
+data "file_local" "encryption_example" {
+  name = "token.enc"
+  # the key is read from the TF_FILE_ENCRYPTION_KEY environment variable
+  encryption {}
+}
+
+# tflint-ignore: terraform_unused_declarations
+data "file_local" "optional_example" {
+  name            = "override.conf"
+  fail_if_missing = false
+}
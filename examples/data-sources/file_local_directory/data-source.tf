@@ -3,3 +3,9 @@
 data "file_local_directory" "basic_example" {
   path = "example_directory"
 }
+
+# tflint-ignore: terraform_unused_declarations
+data "file_local_directory" "optional_example" {
+  path            = "example_directory/conf.d"
+  fail_if_missing = false
+}
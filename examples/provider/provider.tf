@@ -1,4 +1,8 @@
-
-
-# this provider has no configuration currently
-provider "file" {}
+# every argument is optional, resources and data sources override these defaults
+provider "file" {
+  root_directory                = "/etc/example"
+  default_file_permissions      = "0640"
+  default_directory_permissions = "0750"
+  allowed_paths                 = ["/etc/example"]
+  denied_paths                  = ["/etc/example/secrets"]
+}
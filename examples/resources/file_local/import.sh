@@ -1,10 +1,7 @@
+# The import id is the path to the file, the contents and permissions are read from the file.
+terraform import file_local.example "/path/to/file"
 
-
-# echo "Test data" > data.txt
-# FILEPATH="./data.txt"
+# Add the ",protected" suffix to import a protected file.
+# The id is calculated with the provider's hmac_secret_key or the TF_FILE_HMAC_SECRET_KEY environment variable.
 # TF_FILE_HMAC_SECRET_KEY="super-secret-key"
-# IDENTIFIER="$(openssl dgst -sha256 -hmac "$TF_FILE_HMAC_SECRET_KEY" "$FILE" | awk '{print $2}')"
-
-terraform import file_local.example "IDENTIFIER"
-
-# after this is run you will need to refine the resource further by setting the directory and name.
+terraform import file_local.example "/path/to/file,protected"
@@ -19,3 +19,131 @@ resource "file_local" "protected_example" {
   EOF
   id       = "2b13b6d5e32a0a0bd19fe95c44044aed72b677efd9a9db3f9a37f9bb8b0a893e"
 }
+
+resource "file_local" "binary_example" {
+  name            = "example.txt.gz"
+  contents_base64 = base64gzip("Binary data must be base64 encoded, this file is written gzip compressed.")
+}
+
+resource "file_local" "source_example" {
+  name   = "artifact.tar.gz"
+  source = "/path/to/build/artifact.tar.gz"
+}
+
+# requires Terraform 1.11 or later, the configured 'contents' would be saved in the state
+resource "file_local" "hash_only_example" {
+  name                = "generated.conf"
+  contents_wo         = "The file on disk is compared by its sha256 hash, its contents are never saved in the state."
+  contents_wo_version = 1
+  store_contents      = false
+}
+
+# requires Terraform 1.11 or later
+resource "file_local" "write_only_example" {
+  name                = "token"
+  contents_wo         = "This value is written to the file but never saved in the plan or state."
+  contents_wo_version = 1
+}
+
+resource "file_local" "owner_example" {
+  name     = "service.conf"
+  contents = "Owner and group accept names or numeric ids, changing them requires elevated privileges."
+  owner    = "nobody"
+  group    = "1000"
+}
+
+resource "file_local" "rotated_key_example" {
+  name                     = "rotated.txt"
+  contents                 = "The id is recalculated with the new key, the file itself isn't rewritten."
+  protected                = true
+  hmac_secret_key          = "this-is-the-new-example-key"
+  previous_hmac_secret_key = "this-is-an-example-key"
+  id                       = "5e4da5ab83266eb3d60354bba3cf7b4568c5eb3f5b8add41ad797fdb33b44563"
+}
+
+resource "file_local" "sha512_example" {
+  name            = "sha512.txt"
+  contents        = "The id of this file is calculated with HMAC-SHA512."
+  protected       = true
+  hmac_secret_key = "this-is-an-example-key"
+  hmac_algorithm  = "sha512"
+  id              = "6231b03ee92cdcc2d260643cc5479e2e19265f26b7197bcda84bf2d2d96b027825e0c6b6b37c183c2e003c2a45711d3931192c76acffe359457ae4dd7f2c6881"
+}
+
+resource "file_local" "restore_example" {
+  name             = "config.yaml"
+  directory        = "/etc/app"
+  contents         = "A temporary override, the original config.yaml is put back when this resource is destroyed."
+  destroy_behavior = "restore"
+}
+
+resource "file_local" "if_exists_example" {
+  name      = "new.conf"
+  contents  = "The plan fails if new.conf already exists rather than overwriting it."
+  if_exists = "fail"
+}
+
+resource "file_local" "backup_example" {
+  name     = "example_backup.txt"
+  contents = "An example file which keeps backups of its previous contents."
+  backup {
+    count = 3
+  }
+}
+
+resource "file_local" "create_parent_directories_example" {
+  name                      = "nested.txt"
+  directory                 = "path/to/nested"
+  contents                  = "The missing parent directories are created with the file, and removed with it when they are empty."
+  create_parent_directories = true
+  directory_permissions     = "0750"
+}
+
+resource "file_local" "modified_time_example" {
+  name                        = "pinned.txt"
+  contents                    = "Build tools see the same modification time on every apply."
+  modified_time               = "2025-01-01T00:00:00Z"
+  preserve_mtime_if_unchanged = true
+}
+
+resource "file_local" "symbolic_permissions_example" {
+  name        = "script.sh"
+  contents    = "#!/bin/sh\necho 'runs as the owner of the file'\n"
+  permissions = "u=rwxs,g=rx,o="
+}
+
+resource "file_local" "lock_example" {
+  name     = "agent.conf"
+  contents = "The agent on the host locks this file before rewriting it."
+  lock {
+    mode    = "lockfile"
+    timeout = "1m"
+  }
+}
+
+resource "file_local" "selinux_example" {
+  name            = "index.html"
+  directory       = "/var/www/html"
+  contents        = "<h1>Served by httpd without relabeling the file.</h1>"
+  permissions     = "0644"
+  selinux_context = "system_u:object_r:httpd_sys_content_t:s0"
+  xattrs = {
+    "user.deployed_by" = "terraform"
+  }
+}
+
+resource "file_local" "windows_text_example" {
+  name         = "setup.bat"
+  contents     = "@echo off\necho This file has CRLF line endings.\n"
+  line_endings = "crlf"
+  encoding     = "utf-16le"
+}
+
+resource "file_local" "encryption_example" {
+  name        = "kubeconfig"
+  contents    = "apiVersion: v1\nkind: Config\n"
+  permissions = "0600"
+  encryption {
+    recipients = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+  }
+}
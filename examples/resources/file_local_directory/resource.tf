@@ -3,3 +3,21 @@ resource "file_local_directory" "basic_example" {
   path        = "path/to/new/directory"
   permissions = "0700"
 }
+
+resource "file_local_directory" "owner_example" {
+  path        = "path/to/owned/directory"
+  permissions = "0750"
+  owner       = "nobody"
+  group       = "1000"
+}
+
+resource "file_local_directory" "shared_example" {
+  path        = "path/to/shared/directory"
+  permissions = "1777"
+}
+
+resource "file_local_directory" "selinux_example" {
+  path            = "/srv/www/site"
+  permissions     = "0755"
+  selinux_context = "system_u:object_r:httpd_sys_content_t:s0"
+}
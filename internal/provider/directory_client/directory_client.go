@@ -2,9 +2,13 @@ package directory_client
 
 type DirectoryClient interface {
 	Create(path string, permissions string) (string, error) // Base of the newly created path (used in destroy), error
-	// If directory isn't found the error message must have err.Error() == "directory not found"
+	// If directory isn't found errors.Is(err, fs.ErrNotExist) must be true, the error from os.Stat already is
 	Read(path string) (string, map[string]map[string]string, error) // permissions, files info map, error
+	Info(path string) (map[string]string, error)                    // directory info map ("Mode", "Owner", "Group", "Uid", "Gid"), error
 	Update(path string, permissions string) error
+	Chown(path string, owner string, group string) error                                // owner and group are names or numeric ids, empty means unchanged
+	Xattrs(path string) (map[string]string, error)                                      // every extended attribute of the directory, including "security.selinux"
+	SetXattrs(path string, set map[string]string, remove []string) error                // other extended attributes are left alone, an unsupported filesystem is an error
 	Delete(path string) error                                                           // "path" should be the return from Create
 	CreateFile(path string, data string, permissions string, lastModified string) error // create a file in the given directory
 }
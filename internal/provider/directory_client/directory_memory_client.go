@@ -2,12 +2,25 @@ package directory_client
 
 import (
 	"fmt"
+	"io/fs"
+	"maps"
 	"path/filepath"
 	"strings"
 )
 
 var _ DirectoryClient = &MemoryDirectoryClient{} // make sure the MemoryDirectoryClient implements the DirectoryClient
 
+// errNotFound is the memory client's error for a missing directory, it matches fs.ErrNotExist like the errors from the os package.
+type errNotFound struct{}
+
+func (errNotFound) Error() string {
+	return "directory not found"
+}
+
+func (errNotFound) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
 type MemoryDirectoryClient struct {
 	directory map[string]interface{}
 }
@@ -33,13 +46,66 @@ func (c *MemoryDirectoryClient) Create(path string, permissions string) (string,
 
 func (c *MemoryDirectoryClient) Read(_ string) (string, map[string]map[string]string, error) {
 	if c.directory == nil {
-		return "", nil, fmt.Errorf("directory not found")
+		return "", nil, errNotFound{}
 	}
 	permissions, _ := c.directory["permissions"].(string)
 	info, _ := c.directory["info"].(map[string]map[string]string)
 	return permissions, info, nil
 }
 
+func (c *MemoryDirectoryClient) Info(_ string) (map[string]string, error) {
+	if c.directory == nil {
+		return nil, errNotFound{}
+	}
+	permissions, _ := c.directory["permissions"].(string)
+	owner, _ := c.directory["owner"].(string)
+	group, _ := c.directory["group"].(string)
+	return map[string]string{
+		"Mode":  permissions,
+		"Owner": owner,
+		"Group": group,
+		"Uid":   owner,
+		"Gid":   group,
+	}, nil
+}
+
+func (c *MemoryDirectoryClient) Chown(_ string, owner string, group string) error {
+	if c.directory == nil {
+		return errNotFound{}
+	}
+	if owner != "" {
+		c.directory["owner"] = owner
+	}
+	if group != "" {
+		c.directory["group"] = group
+	}
+	return nil
+}
+
+func (c *MemoryDirectoryClient) Xattrs(_ string) (map[string]string, error) {
+	if c.directory == nil {
+		return nil, errNotFound{}
+	}
+	xattrs, _ := c.directory["xattrs"].(map[string]string)
+	return maps.Clone(xattrs), nil
+}
+
+func (c *MemoryDirectoryClient) SetXattrs(_ string, set map[string]string, remove []string) error {
+	if c.directory == nil {
+		return errNotFound{}
+	}
+	xattrs, _ := c.directory["xattrs"].(map[string]string)
+	if xattrs == nil {
+		xattrs = map[string]string{}
+	}
+	for _, name := range remove {
+		delete(xattrs, name)
+	}
+	maps.Copy(xattrs, set)
+	c.directory["xattrs"] = xattrs
+	return nil
+}
+
 func (c *MemoryDirectoryClient) Update(_ string, permissions string) error {
 	c.directory["permissions"] = permissions
 	return nil
@@ -52,7 +118,7 @@ func (c *MemoryDirectoryClient) Delete(_ string) error {
 
 func (c *MemoryDirectoryClient) CreateFile(path string, data string, permissions string, lastModified string) error {
 	if c.directory == nil {
-		return fmt.Errorf("directory not found")
+		return errNotFound{}
 	}
 	info, ok := c.directory["info"].(map[string]map[string]string)
 	if !ok {
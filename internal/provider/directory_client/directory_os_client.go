@@ -5,15 +5,25 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/permissions"
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
 )
 
 var _ DirectoryClient = &OsDirectoryClient{} // make sure the OsDirectoryClient implements the DirectoryClient
 
 type OsDirectoryClient struct {
+	// Every path is checked against the sandbox before it is touched, a nil sandbox allows everything.
+	Sandbox *sandbox.Sandbox
 }
 
 func (c *OsDirectoryClient) Create(path string, permissions string) (string, error) {
-	created, err := makePath(path, permissions)
+	if err := c.Sandbox.Check(path); err != nil {
+		return "", err
+	}
+	created, err := MakePath(path, permissions, c.Sandbox)
 	if len(created) > 0 {
 		fmt.Printf("created: %#v", created)
 		return created[0], err
@@ -22,6 +32,9 @@ func (c *OsDirectoryClient) Create(path string, permissions string) (string, err
 }
 
 func (c *OsDirectoryClient) Read(path string) (string, map[string]map[string]string, error) {
+	if err := c.Sandbox.Check(path); err != nil {
+		return "", nil, err
+	}
 	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -29,7 +42,7 @@ func (c *OsDirectoryClient) Read(path string) (string, map[string]map[string]str
 		}
 		return "", nil, err
 	}
-	mode := fmt.Sprintf("%#o", info.Mode().Perm())
+	mode := permissions.Format(info.Mode())
 
 	data, err := os.ReadDir(path)
 	if err != nil {
@@ -47,23 +60,69 @@ func (c *OsDirectoryClient) Read(path string) (string, map[string]map[string]str
 		} else {
 			isDir = "false"
 		}
-		files[file.Name()] = map[string]string{
-			"Size":    strconv.FormatInt(fileInfo.Size(), 10),
-			"Mode":    fmt.Sprintf("%#o", fileInfo.Mode().Perm()),
-			"ModTime": fileInfo.ModTime().String(),
-			"IsDir":   isDir,
-		}
+		files[file.Name()] = ownership.FromFileInfo(fileInfo)
+		files[file.Name()]["Size"] = strconv.FormatInt(fileInfo.Size(), 10)
+		files[file.Name()]["Mode"] = permissions.Format(fileInfo.Mode())
+		files[file.Name()]["ModTime"] = fileInfo.ModTime().String()
+		files[file.Name()]["IsDir"] = isDir
 	}
 	return mode, files, nil
 }
 
+func (c *OsDirectoryClient) Info(path string) (map[string]string, error) {
+	if err := c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	dirInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	info := ownership.FromFileInfo(dirInfo)
+	info["Mode"] = permissions.Format(dirInfo.Mode())
+	return info, nil
+}
+
+func (c *OsDirectoryClient) Chown(path string, owner string, group string) error {
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	uid, gid, err := ownership.Resolve(owner, group)
+	if err != nil {
+		return err
+	}
+	if uid == -1 && gid == -1 {
+		return nil
+	}
+	return ownership.Chown(path, uid, gid)
+}
+
+func (c *OsDirectoryClient) Xattrs(path string) (map[string]string, error) {
+	if err := c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	return xattr.List(path)
+}
+
+func (c *OsDirectoryClient) SetXattrs(path string, set map[string]string, remove []string) error {
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	if err := xattr.Remove(path, remove); err != nil {
+		return err
+	}
+	return xattr.Set(path, set)
+}
+
 // The only thing that can be updated is the permissions.
-func (c *OsDirectoryClient) Update(path string, permissions string) error {
-	modeInt, err := strconv.ParseUint(permissions, 8, 32)
+func (c *OsDirectoryClient) Update(path string, perms string) error {
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	err = os.Chmod(path, os.FileMode(modeInt))
+	err = os.Chmod(path, mode)
 	if err != nil {
 		return err
 	}
@@ -74,10 +133,18 @@ func (c *OsDirectoryClient) Delete(path string) error {
 	if path == "" {
 		return nil
 	}
+	// This is a recursive removal, never allow it to escape the sandbox.
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
 	return os.RemoveAll(path)
 }
 
-func makePath(path string, permissions string) ([]string, error) {
+// MakePath creates the directory at path and any missing parents with the given permissions.
+// The directories which were created are returned from the top down, an existing path returns the empty list.
+// Every missing directory is checked against the sandbox before anything is created,
+// so a parent outside of the allowed paths is an error rather than a directory which can't be removed later.
+func MakePath(path string, perms string, s *sandbox.Sandbox) ([]string, error) {
 	var created []string
 	info, err := os.Stat(path)
 	if err == nil {
@@ -100,10 +167,14 @@ func makePath(path string, permissions string) ([]string, error) {
 		// This breaks the recursion.
 		return created, nil
 	}
+	// The recursion checks every missing directory on the way up, the directories are only created on the way back down.
+	if err := s.Check(path); err != nil {
+		return nil, err
+	}
 
 	// Start a recursion.
 	// This will recurse until path = parent, where parentCreated will be the empty list.
-	parentCreated, err := makePath(parent, permissions)
+	parentCreated, err := MakePath(parent, perms, s)
 	if err != nil {
 		return nil, err
 	}
@@ -122,11 +193,15 @@ func makePath(path string, permissions string) ([]string, error) {
 	// This means the previous recursion must have successfully generated a directory.
 	// In any recursion cycle from this point the parent directory exists and is valid.
 
-	modeInt, err := strconv.ParseUint(permissions, 8, 32)
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return nil, err
 	}
-	if err := os.Mkdir(path, os.FileMode(modeInt)); err != nil {
+	if err := os.Mkdir(path, mode); err != nil {
+		return nil, err
+	}
+	// mkdir is subject to the umask and ignores the special bits, chmod gives the directory exactly the requested mode
+	if err := os.Chmod(path, mode); err != nil {
 		return nil, err
 	}
 	// We successfully created the directory, add it to our list.
@@ -136,10 +211,16 @@ func makePath(path string, permissions string) ([]string, error) {
 }
 
 // Added to help with testing, use the file client to create files in production.
-func (c *OsDirectoryClient) CreateFile(path string, data string, permissions string, _ string) error {
-	modeInt, err := strconv.ParseUint(permissions, 8, 32)
+func (c *OsDirectoryClient) CreateFile(path string, data string, perms string, _ string) error {
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, []byte(data), os.FileMode(modeInt))
+	if err = os.WriteFile(path, []byte(data), mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, mode) // WriteFile is subject to the umask
 }
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// The headers which start encrypted files, they say how the rest of the file is decrypted.
+const (
+	ageHeader = "age-encryption.org/v1\n"
+	aesHeader = "terraform-provider-file/aes-256-gcm/v1\n"
+)
+
+// ErrNotEncrypted is returned when decrypting a file which doesn't start with either header.
+var ErrNotEncrypted = errors.New("the file isn't encrypted")
+
+// Encrypt encrypts the plaintext for the age recipients, or with the AES-256 key when there are no recipients.
+// Every call uses a new file key or nonce, encrypting the same plaintext twice gives different ciphertexts.
+func Encrypt(plaintext string, recipients []string, key string) (string, error) {
+	if len(recipients) > 0 {
+		return encryptAge(plaintext, recipients)
+	}
+	if key == "" {
+		return "", errors.New("encrypting needs age recipients or an AES-256 key")
+	}
+	return encryptAES(plaintext, key)
+}
+
+// Decrypt decrypts a file written by Encrypt, the header of the file says whether it needs the age identity or the key.
+func Decrypt(ciphertext string, identity string, key string) (string, error) {
+	switch {
+	case strings.HasPrefix(ciphertext, ageHeader):
+		if identity == "" {
+			return "", errors.New("the file is encrypted with age, decrypting it needs the identity of one of its recipients")
+		}
+		return decryptAge(ciphertext, identity)
+	case strings.HasPrefix(ciphertext, aesHeader):
+		if key == "" {
+			return "", errors.New("the file is encrypted with AES-256-GCM, decrypting it needs the key")
+		}
+		return decryptAES(ciphertext, key)
+	default:
+		return "", ErrNotEncrypted
+	}
+}
+
+func encryptAge(plaintext string, recipients []string) (string, error) {
+	parsed, err := age.ParseRecipients(strings.NewReader(strings.Join(recipients, "\n")))
+	if err != nil {
+		return "", fmt.Errorf("problem parsing age recipients: %w", err)
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, parsed...)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.WriteString(w, plaintext); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func decryptAge(ciphertext string, identity string) (string, error) {
+	identities, err := age.ParseIdentities(strings.NewReader(identity))
+	if err != nil {
+		return "", fmt.Errorf("problem parsing age identity: %w", err)
+	}
+	r, err := age.Decrypt(strings.NewReader(ciphertext), identities...)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// encryptAES writes the header, the nonce, then the sealed plaintext, the header is authenticated with it.
+func encryptAES(plaintext string, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, nonce, []byte(plaintext), []byte(aesHeader))
+	return aesHeader + string(nonce) + string(sealed), nil
+}
+
+func decryptAES(ciphertext string, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	data := []byte(strings.TrimPrefix(ciphertext, aesHeader))
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("the encrypted file is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(aesHeader))
+	if err != nil {
+		return "", errors.New("the file can't be decrypted with the key, the key is wrong or the file was changed")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	raw, err := ParseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseKey decodes a base64 encoded AES-256 key, eg. from 'openssl rand -base64 32'.
+func ParseKey(key string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("the key isn't valid base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("the key is %d bytes, AES-256 keys are 32 bytes", len(raw))
+	}
+	return raw, nil
+}
+
+// KeyValidator checks keys while planning.
+func KeyValidator() validator.String {
+	return keyValidator{}
+}
+
+type keyValidator struct{}
+
+func (v keyValidator) Description(_ context.Context) string {
+	return "value must be a base64 encoded 32 byte key, eg. from 'openssl rand -base64 32'"
+}
+
+func (v keyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v keyValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := ParseKey(req.ConfigValue.ValueString()); err != nil {
+		// the key is sensitive, so the value isn't repeated in the error
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid key", v.Description(ctx)+": "+err.Error())
+	}
+}
+
+// RecipientValidator checks age recipients while planning.
+func RecipientValidator() validator.String {
+	return recipientValidator{}
+}
+
+type recipientValidator struct{}
+
+func (v recipientValidator) Description(_ context.Context) string {
+	return "value must be an age recipient, eg. 'age1...'"
+}
+
+func (v recipientValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v recipientValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := age.ParseRecipients(strings.NewReader(req.ConfigValue.ValueString())); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid recipient",
+			fmt.Sprintf("The value '%s' isn't valid, %s: %s", req.ConfigValue.ValueString(), v.Description(ctx), err.Error()))
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package encryption
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+const testKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // printf '0123456789abcdef0123456789abcdef' | base64
+
+func TestEncryptDecrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	testCases := []struct {
+		name       string
+		recipients []string
+		identity   string
+		key        string
+	}{
+		{"Age", []string{identity.Recipient().String()}, identity.String(), ""},
+		{"AES", nil, "", testKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plaintext := "this is an encryption test\n"
+			ciphertext, err := Encrypt(plaintext, tc.recipients, tc.key)
+			if err != nil {
+				t.Fatalf("Encrypt() error: %v", err)
+			}
+			if strings.Contains(ciphertext, plaintext) {
+				t.Errorf("Encrypt() ciphertext contains the plaintext")
+			}
+			again, err := Encrypt(plaintext, tc.recipients, tc.key)
+			if err != nil {
+				t.Fatalf("Encrypt() error: %v", err)
+			}
+			if again == ciphertext {
+				t.Errorf("Encrypt() gave the same ciphertext twice")
+			}
+			got, err := Decrypt(ciphertext, tc.identity, tc.key)
+			if err != nil {
+				t.Fatalf("Decrypt() error: %v", err)
+			}
+			if got != plaintext {
+				t.Errorf("Decrypt() is %q; want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	aesCiphertext, err := Encrypt("this is an encryption test", nil, testKey)
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	otherKey := "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=" // printf 'fedcba9876543210fedcba9876543210' | base64
+	testCases := []struct {
+		name       string
+		ciphertext string
+		key        string
+		want       string
+	}{
+		{"Not encrypted", "this is an encryption test", testKey, ErrNotEncrypted.Error()},
+		{"Wrong key", aesCiphertext, otherKey, "can't be decrypted with the key"},
+		{"Changed", aesCiphertext[:len(aesCiphertext)-1] + "x", testKey, "can't be decrypted with the key"},
+		{"No key", aesCiphertext, "", "needs the key"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decrypt(tc.ciphertext, "", tc.key)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Decrypt() error is %v; want an error containing %q", err, tc.want)
+			}
+			if tc.name == "Not encrypted" && !errors.Is(err, ErrNotEncrypted) {
+				t.Errorf("Decrypt() error is %v; want ErrNotEncrypted", err)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		key       string
+		wantError bool
+	}{
+		{"Valid", testKey, false},
+		{"Trailing newline", testKey + "\n", false},
+		{"Short", "MDEyMzQ1Njc4OWFiY2RlZg==", true},
+		{"Not base64", "not a key", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseKey(tc.key)
+			if (err != nil) != tc.wantError {
+				t.Errorf("ParseKey() error is %v; want error: %t", err, tc.wantError)
+			}
+		})
+	}
+}
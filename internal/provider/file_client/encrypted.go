@@ -0,0 +1,73 @@
+package file_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
+)
+
+// EncryptedFileClient encrypts the contents before they are written, so only the ciphertext reaches the disk,
+// and decrypts them as they are read.
+// Files are encrypted for the age Recipients, or with the base64 encoded AES-256 Key when there are no recipients.
+// Reading age encrypted files needs the Identity of one of the recipients.
+// Only Create, Update, Read, Open, and Hash see the plaintext, everything else works on the encrypted file.
+type EncryptedFileClient struct {
+	FileClient
+	Recipients []string
+	Key        string
+	Identity   string
+}
+
+var _ FileClient = &EncryptedFileClient{} // make sure the EncryptedFileClient implements the FileClient
+
+func (c *EncryptedFileClient) Create(directory string, name string, data string, permissions string) error {
+	encrypted, err := encryption.Encrypt(data, c.Recipients, c.Key)
+	if err != nil {
+		return err
+	}
+	return c.FileClient.Create(directory, name, encrypted, permissions)
+}
+
+func (c *EncryptedFileClient) Update(currentDirectory string, currentName string, newDirectory string, newName string, data string, permissions string) error {
+	encrypted, err := encryption.Encrypt(data, c.Recipients, c.Key)
+	if err != nil {
+		return err
+	}
+	return c.FileClient.Update(currentDirectory, currentName, newDirectory, newName, encrypted, permissions)
+}
+
+// Read returns the plaintext, the error wraps encryption.ErrNotEncrypted when the file isn't encrypted.
+func (c *EncryptedFileClient) Read(directory string, name string) (string, string, error) {
+	permissions, data, err := c.FileClient.Read(directory, name)
+	if err != nil {
+		return "", "", err
+	}
+	decrypted, err := encryption.Decrypt(data, c.Identity, c.Key)
+	if err != nil {
+		return "", "", fmt.Errorf("problem decrypting '%s': %w", name, err)
+	}
+	return permissions, decrypted, nil
+}
+
+// Open decrypts the whole file into memory, the plaintext is never written to disk.
+func (c *EncryptedFileClient) Open(directory string, name string) (io.ReadCloser, error) {
+	_, decrypted, err := c.Read(directory, name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(decrypted)), nil
+}
+
+// Hash returns the hash of the plaintext.
+func (c *EncryptedFileClient) Hash(directory string, name string) (string, error) {
+	_, decrypted, err := c.Read(directory, name)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(decrypted))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -1,14 +1,40 @@
 package file_client
 
+import (
+	"io"
+	"time"
+)
+
 type FileClient interface {
 	Create(directory string, name string, data string, permissions string) error
+	CreateFrom(sourcePath string, directory string, name string, permissions string) error // stream an existing file to the new file
 	// If file isn't found the error message must have err.Error() == "file not found"
 	Read(directory string, name string) (string, string, error) // permissions, contents, error
+	// Info and Open don't read the contents into memory, if file isn't found the error message must have err.Error() == "file not found"
+	// The times are RFC 3339 strings in UTC, the memory client leaves them empty until they are set.
+	Info(directory string, name string) (map[string]string, error) // file info map ("Mode", "Size", "Owner", "Group", "Uid", "Gid", "ModifiedTime", "AccessTime"), error
+	Open(directory string, name string) (io.ReadCloser, error)     // the caller must close the reader
 	Update(currentDirectory string, currentName string, newDirectory string, newName string, data string, permissions string) error
+	Move(currentDirectory string, currentName string, newDirectory string, newName string, permissions string) error // rename and chmod the file without rewriting it
+	SetTimes(directory string, name string, modified string, accessed string) error                                  // RFC 3339 times, empty leaves the time unchanged
+	MakeDirectory(directory string, permissions string) ([]string, error)                                            // create the directory and any missing parents, returns the directories created
+	DeleteDirectory(directory string) (bool, error)                                                                  // remove the directory only when it's empty, returns whether it's gone
 	Delete(directory string, name string) error
+	Chown(directory string, name string, owner string, group string) error // owner and group are names or numeric ids, empty means unchanged
+	// Extended attributes include the SELinux context, "security.selinux", an unsupported filesystem is an error when setting them.
+	Xattrs(directory string, name string) (map[string]string, error)                       // every extended attribute of the file
+	SetXattrs(directory string, name string, set map[string]string, remove []string) error // other extended attributes are left alone
+	// Lock takes an advisory lock for the file, both modes use a sibling "<name>.lock" file, "flock" locks it with flock and "lockfile" creates it.
+	// It waits up to the timeout for another holder, then fails with a *lock.HeldError, the returned function releases the lock.
+	Lock(directory string, name string, mode string, timeout time.Duration) (func() error, error)
 
 	Compress(directory string, name string, compressedName string) error
 	Encode(directory string, name string, encodedName string) error
 	Hash(directory string, name string) (string, error) // Sha256Hash, error
 	Copy(currentPath string, newPath string) error
+	// Backup copies the file to a new backup next to it, then prunes the oldest backups so only count are kept.
+	// The format is "numbered" (name.1 is the newest) or "timestamp" (name.<UTC time>), the backup paths are returned newest first.
+	// Only the previous backups, newest first as they were returned, are renamed or pruned, an existing file which isn't one of them is an error.
+	// The previous backups may be in another directory or have another name when the file moved, they are still renamed or pruned.
+	Backup(directory string, name string, count int, format string, previous []string) ([]string, error)
 }
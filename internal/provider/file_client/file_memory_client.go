@@ -7,11 +7,22 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"maps"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rancher/terraform-provider-file/internal/provider/lock"
 )
 
 type MemoryFileClient struct {
-	file map[string]string
+	file        map[string]string
+	backups     []string          // contents of the backups, newest first
+	directories []string          // directories made by MakeDirectory
+	xattrs      map[string]string // extended attributes of the file
+	locks       []string          // paths which are locked
 }
 
 var _ FileClient = &MemoryFileClient{} // make sure the MemoryFileClient implements the FileClient
@@ -23,9 +34,94 @@ func (c *MemoryFileClient) Create(directory string, name string, data string, pe
 	c.file["name"] = name
 	c.file["contents"] = data
 	c.file["permissions"] = permissions
+	c.xattrs = nil // a new file has no extended attributes
+	return nil
+}
+
+// The memory client only holds one file, so the source is moved to the new location just like Copy.
+func (c *MemoryFileClient) CreateFrom(sourcePath string, directory string, name string, permissions string) error {
+	if c.file == nil || filepath.Join(c.file["directory"], c.file["name"]) != filepath.Clean(sourcePath) {
+		return fmt.Errorf("file not found")
+	}
+	c.file["directory"] = directory
+	c.file["name"] = name
+	c.file["permissions"] = permissions
 	return nil
 }
 
+func (c *MemoryFileClient) Info(_ string, _ string) (map[string]string, error) {
+	if c.file["directory"] == "" || c.file["name"] == "" {
+		return nil, fmt.Errorf("file not found")
+	}
+	return map[string]string{
+		"Mode":  c.file["permissions"],
+		"Size":  fmt.Sprintf("%d", len(c.file["contents"])),
+		"Owner": c.file["owner"],
+		"Group": c.file["group"],
+		"Uid":   c.file["owner"],
+		"Gid":   c.file["group"],
+
+		"ModifiedTime": c.file["modified"],
+		"AccessTime":   c.file["accessed"],
+	}, nil
+}
+
+func (c *MemoryFileClient) Xattrs(_ string, _ string) (map[string]string, error) {
+	if c.file == nil {
+		return nil, fmt.Errorf("file not found")
+	}
+	return maps.Clone(c.xattrs), nil
+}
+
+func (c *MemoryFileClient) SetXattrs(_ string, _ string, set map[string]string, remove []string) error {
+	if c.file == nil {
+		return fmt.Errorf("file not found")
+	}
+	if c.xattrs == nil {
+		c.xattrs = map[string]string{}
+	}
+	for _, name := range remove {
+		delete(c.xattrs, name)
+	}
+	maps.Copy(c.xattrs, set)
+	return nil
+}
+
+func (c *MemoryFileClient) Lock(directory string, name string, mode string, timeout time.Duration) (func() error, error) {
+	path := filepath.Join(directory, name)
+	if mode != lock.Flock && mode != lock.Lockfile {
+		return nil, fmt.Errorf("unknown lock mode '%s'", mode)
+	}
+	if slices.Contains(c.locks, path) {
+		return nil, &lock.HeldError{Path: path, Holder: "another memory client lock", Timeout: timeout}
+	}
+	c.locks = append(c.locks, path)
+	return func() error {
+		c.locks = slices.DeleteFunc(c.locks, func(p string) bool { return p == path })
+		return nil
+	}, nil
+}
+
+func (c *MemoryFileClient) Chown(_ string, _ string, owner string, group string) error {
+	if c.file["directory"] == "" || c.file["name"] == "" {
+		return fmt.Errorf("file not found")
+	}
+	if owner != "" {
+		c.file["owner"] = owner
+	}
+	if group != "" {
+		c.file["group"] = group
+	}
+	return nil
+}
+
+func (c *MemoryFileClient) Open(_ string, _ string) (io.ReadCloser, error) {
+	if c.file["directory"] == "" || c.file["name"] == "" {
+		return nil, fmt.Errorf("file not found")
+	}
+	return io.NopCloser(strings.NewReader(c.file["contents"])), nil
+}
+
 func (c *MemoryFileClient) Read(_ string, _ string) (string, string, error) {
 	if c.file["directory"] == "" || c.file["name"] == "" {
 		return "", "", fmt.Errorf("file not found")
@@ -42,9 +138,50 @@ func (c *MemoryFileClient) Update(_ string, _ string, newDirectory string, newNa
 	return nil
 }
 
+func (c *MemoryFileClient) Move(_ string, _ string, newDirectory string, newName string, permissions string) error {
+	if c.file == nil {
+		return fmt.Errorf("file not found")
+	}
+	c.file["directory"] = newDirectory
+	c.file["name"] = newName
+	c.file["permissions"] = permissions
+	return nil
+}
+
+func (c *MemoryFileClient) SetTimes(_ string, _ string, modified string, accessed string) error {
+	if c.file == nil {
+		return fmt.Errorf("file not found")
+	}
+	if modified != "" {
+		c.file["modified"] = modified
+	}
+	if accessed != "" {
+		c.file["accessed"] = accessed
+	}
+	return nil
+}
+
+// MakeDirectory only remembers the directory, it is reported as created the first time it's made.
+func (c *MemoryFileClient) MakeDirectory(directory string, _ string) ([]string, error) {
+	if slices.Contains(c.directories, directory) {
+		return []string{}, nil
+	}
+	c.directories = append(c.directories, directory)
+	return []string{directory}, nil
+}
+
+func (c *MemoryFileClient) DeleteDirectory(directory string) (bool, error) {
+	if c.file != nil && c.file["directory"] == directory {
+		return false, nil
+	}
+	c.directories = slices.DeleteFunc(c.directories, func(d string) bool { return d == directory })
+	return true, nil
+}
+
 func (c *MemoryFileClient) Delete(directory string, name string) error {
 	if c.file["directory"] == directory && c.file["name"] == name {
 		c.file = nil
+		c.xattrs = nil
 	}
 	return nil
 }
@@ -85,6 +222,20 @@ func (c *MemoryFileClient) Hash(_ string, _ string) (string, error) {
 	return hashString, nil
 }
 
+// The memory client doesn't have a clock, so timestamped backups are numbered too.
+func (c *MemoryFileClient) Backup(directory string, name string, count int, _ string, _ []string) ([]string, error) {
+	if c.file["directory"] == "" || c.file["name"] == "" {
+		return nil, fmt.Errorf("file not found")
+	}
+	c.backups = append([]string{c.file["contents"]}, c.backups...)
+	c.backups = c.backups[:min(count, len(c.backups))]
+	paths := make([]string, 0, len(c.backups))
+	for i := range c.backups {
+		paths = append(paths, fmt.Sprintf("%s.%d", filepath.Join(directory, name), i+1))
+	}
+	return paths, nil
+}
+
 func (c *MemoryFileClient) Copy(_ string, newPath string) error {
 	c.file["directory"] = filepath.Dir(newPath)
 	c.file["name"] = filepath.Base(newPath)
@@ -5,20 +5,37 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rancher/terraform-provider-file/internal/provider/directory_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/lock"
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/permissions"
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
 )
 
 // The default FileClient, using the os package.
-type OsFileClient struct{}
+type OsFileClient struct {
+	// Every path is checked against the sandbox before it is touched, a nil sandbox allows everything.
+	Sandbox *sandbox.Sandbox
+	// When true the parent directory is also synced after a file is written,
+	// this makes sure the rename of the temporary file survives a crash.
+	SyncDirectory bool
+}
 
 var _ FileClient = &OsFileClient{} // make sure the OsFileClient implements the FileClient
 
-func (c *OsFileClient) Create(directory string, name string, data string, permissions string) (err error) {
+func (c *OsFileClient) Create(directory string, name string, data string, perms string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic during file creation: %v", r)
@@ -26,17 +43,124 @@ func (c *OsFileClient) Create(directory string, name string, data string, permis
 	}()
 
 	path := filepath.Join(directory, name)
-	modeInt, err := strconv.ParseUint(permissions, 8, 32)
+	if err = c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(modeInt))
+	return c.write(path, strings.NewReader(data), mode)
+}
+
+// CreateFrom streams the file at sourcePath to the new file without reading it into memory.
+func (c *OsFileClient) CreateFrom(sourcePath string, directory string, name string, perms string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during file creation: %v", r)
+		}
+	}()
+
+	path := filepath.Join(directory, name)
+	if err = c.checkPaths(sourcePath, path); err != nil {
+		return err
+	}
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = io.WriteString(f, data)
-	return err
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return c.write(path, source, mode)
+}
+
+func (c *OsFileClient) Info(directory string, name string) (info map[string]string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during file stat: %v", r)
+		}
+	}()
+
+	path := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	info = ownership.FromFileInfo(fileInfo)
+	info["Mode"] = permissions.Format(fileInfo.Mode())
+	info["Size"] = strconv.FormatInt(fileInfo.Size(), 10)
+	info["ModifiedTime"] = fileInfo.ModTime().UTC().Format(time.RFC3339Nano)
+	info["AccessTime"] = accessTime(fileInfo).UTC().Format(time.RFC3339Nano)
+	return info, nil
+}
+
+func (c *OsFileClient) Chown(directory string, name string, owner string, group string) error {
+	path := filepath.Join(directory, name)
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	uid, gid, err := ownership.Resolve(owner, group)
+	if err != nil {
+		return err
+	}
+	if uid == -1 && gid == -1 {
+		return nil
+	}
+	return ownership.Chown(path, uid, gid)
+}
+
+func (c *OsFileClient) Xattrs(directory string, name string) (map[string]string, error) {
+	path := filepath.Join(directory, name)
+	if err := c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+	return xattr.List(path)
+}
+
+func (c *OsFileClient) SetXattrs(directory string, name string, set map[string]string, remove []string) error {
+	path := filepath.Join(directory, name)
+	if err := c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	if err := xattr.Remove(path, remove); err != nil {
+		return err
+	}
+	return xattr.Set(path, set)
+}
+
+func (c *OsFileClient) Lock(directory string, name string, mode string, timeout time.Duration) (func() error, error) {
+	path := filepath.Join(directory, name)
+	if err := c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	return lock.Acquire(path, mode, timeout)
+}
+
+func (c *OsFileClient) Open(directory string, name string) (io.ReadCloser, error) {
+	path := filepath.Join(directory, name)
+	if err := c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
 }
 
 func (c *OsFileClient) Read(directory string, name string) (rMode string, rContents string, err error) {
@@ -47,6 +171,9 @@ func (c *OsFileClient) Read(directory string, name string) (rMode string, rConte
 	}()
 
 	path := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(path); err != nil {
+		return "", "", err
+	}
 	info, err := os.Stat(path)
 	if err != nil && os.IsNotExist(err) {
 		return "", "", fmt.Errorf("file not found")
@@ -54,7 +181,7 @@ func (c *OsFileClient) Read(directory string, name string) (rMode string, rConte
 	if err != nil {
 		return "", "", err
 	}
-	mode := fmt.Sprintf("%#o", info.Mode().Perm())
+	mode := permissions.Format(info.Mode())
 	f, err := os.Open(path)
 	if err != nil {
 		return "", "", err
@@ -68,7 +195,7 @@ func (c *OsFileClient) Read(directory string, name string) (rMode string, rConte
 	return mode, builder.String(), nil
 }
 
-func (c *OsFileClient) Update(currentDirectory string, currentName string, newDirectory string, newName string, data string, permissions string) (err error) {
+func (c *OsFileClient) Update(currentDirectory string, currentName string, newDirectory string, newName string, data string, perms string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic during file update: %v", r)
@@ -77,26 +204,149 @@ func (c *OsFileClient) Update(currentDirectory string, currentName string, newDi
 
 	currentPath := filepath.Join(currentDirectory, currentName)
 	newPath := filepath.Join(newDirectory, newName)
+	if err = c.Sandbox.Check(currentPath); err != nil {
+		return err
+	}
+	if err = c.Sandbox.Check(newPath); err != nil {
+		return err
+	}
 	if currentPath != newPath {
-		err = os.Rename(currentPath, newPath)
-		if err != nil {
+		if err = c.rename(currentPath, newPath); err != nil {
 			return err
 		}
 	}
-	modeInt, err := strconv.ParseUint(permissions, 8, 32)
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	f, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(modeInt))
+	return c.write(newPath, strings.NewReader(data), mode)
+}
+
+func (c *OsFileClient) Move(currentDirectory string, currentName string, newDirectory string, newName string, perms string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during file move: %v", r)
+		}
+	}()
+
+	currentPath := filepath.Join(currentDirectory, currentName)
+	newPath := filepath.Join(newDirectory, newName)
+	if err = c.checkPaths(currentPath, newPath); err != nil {
+		return err
+	}
+	mode, err := permissions.Parse(perms)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	if currentPath != newPath {
+		if err = c.rename(currentPath, newPath); err != nil {
+			return err
+		}
+	}
+	return os.Chmod(newPath, mode)
+}
+
+func (c *OsFileClient) SetTimes(directory string, name string, modified string, accessed string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during file time update: %v", r)
+		}
+	}()
+
+	path := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(path); err != nil {
+		return err
+	}
+	// a zero time leaves the file's time unchanged
+	var mtime, atime time.Time
+	if modified != "" {
+		if mtime, err = time.Parse(time.RFC3339Nano, modified); err != nil {
+			return err
+		}
+	}
+	if accessed != "" {
+		if atime, err = time.Parse(time.RFC3339Nano, accessed); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(path, atime, mtime)
+}
 
-	_, err = io.WriteString(f, data)
+// These are replaced in tests, to make a rename fail as it does across filesystems,
+// to check the copy is in place when the original is removed, and to see which directories are synced.
+var (
+	renameFile = os.Rename
+	removeFile = os.Remove
+	syncParent = syncDirectory
+)
+
+// rename moves the file, falling back to a copy when rename can't cross filesystems, eg. from a tmpfs or through a bind mount.
+func (c *OsFileClient) rename(currentPath string, newPath string) error {
+	err := renameFile(currentPath, newPath)
+	if errors.Is(err, syscall.EXDEV) {
+		err = c.move(currentPath, newPath)
+	}
 	return err
 }
 
+// move streams the file to a path on another filesystem, keeping its mode and owner, then removes the original.
+func (c *OsFileClient) move(currentPath string, newPath string) error {
+	info, err := os.Stat(currentPath)
+	if err != nil {
+		return err
+	}
+	source, err := os.Open(currentPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	// write syncs the new file before renaming it into place, the original is only removed once the copy is safe
+	if err = c.write(newPath, source, info.Mode()&fileModeBits); err != nil {
+		return err
+	}
+	keepOwner(newPath, info)
+	return removeFile(currentPath)
+}
+
+func (c *OsFileClient) MakeDirectory(directory string, permissions string) (created []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during directory creation: %v", r)
+		}
+	}()
+
+	if err = c.Sandbox.Check(directory); err != nil {
+		return nil, err
+	}
+	return directory_client.MakePath(directory, permissions, c.Sandbox)
+}
+
+func (c *OsFileClient) DeleteDirectory(directory string) (removed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during directory deletion: %v", r)
+		}
+	}()
+
+	if err = c.Sandbox.Check(directory); err != nil {
+		return false, err
+	}
+	entries, err := os.ReadDir(directory)
+	if err != nil && os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(entries) > 0 {
+		return false, nil // something else is using the directory, leave it
+	}
+	if err = os.Remove(directory); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (c *OsFileClient) Delete(directory string, name string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -105,6 +355,9 @@ func (c *OsFileClient) Delete(directory string, name string) (err error) {
 	}()
 
 	path := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(path); err != nil {
+		return err
+	}
 	return os.RemoveAll(path)
 }
 
@@ -116,6 +369,10 @@ func (c *OsFileClient) Compress(directory string, name string, outputName string
 	}()
 
 	inFilePath := filepath.Join(directory, name)
+	outFilePath := filepath.Join(directory, outputName)
+	if err = c.checkPaths(inFilePath, outFilePath); err != nil {
+		return err
+	}
 	inFile, err := os.Open(inFilePath)
 	if err != nil {
 		return err
@@ -123,7 +380,6 @@ func (c *OsFileClient) Compress(directory string, name string, outputName string
 	defer inFile.Close()
 
 	// Create a tmp file to hold compressed data during conversion
-	outFilePath := filepath.Join(directory, outputName)
 	outFile, err := os.Create(outFilePath)
 	if err != nil {
 		return err
@@ -153,6 +409,10 @@ func (c *OsFileClient) Encode(directory string, name string, outputName string)
 	}()
 
 	inFilePath := filepath.Join(directory, name)
+	outFilePath := filepath.Join(directory, outputName)
+	if err = c.checkPaths(inFilePath, outFilePath); err != nil {
+		return err
+	}
 	inFile, err := os.Open(inFilePath)
 	if err != nil {
 		return err
@@ -160,7 +420,6 @@ func (c *OsFileClient) Encode(directory string, name string, outputName string)
 	defer inFile.Close()
 
 	// Create a tmp file to hold encoded data during conversion
-	outFilePath := filepath.Join(directory, outputName)
 	outFile, err := os.Create(outFilePath)
 	if err != nil {
 		return err
@@ -186,6 +445,9 @@ func (c *OsFileClient) Hash(directory string, name string) (hashStr string, err
 	}()
 
 	filePath := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(filePath); err != nil {
+		return "", err
+	}
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
@@ -209,6 +471,9 @@ func (c *OsFileClient) Copy(currentPath string, newPath string) (err error) {
 		}
 	}()
 
+	if err = c.checkPaths(currentPath, newPath); err != nil {
+		return err
+	}
 	srcFile, err := os.Open(currentPath)
 	if err != nil {
 		return err
@@ -228,3 +493,174 @@ func (c *OsFileClient) Copy(currentPath string, newPath string) (err error) {
 
 	return nil
 }
+
+// fileModeBits are the parts of a mode which are copied along with a file, the permissions and the special bits.
+const fileModeBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// backupTimeFormat sorts lexically in time order, the nanoseconds keep backups made in the same second apart.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+func (c *OsFileClient) Backup(directory string, name string, count int, format string, previous []string) (backups []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during file backup: %v", r)
+		}
+	}()
+
+	path := filepath.Join(directory, name)
+	if err = c.Sandbox.Check(path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// only backups made for this file are renamed or removed, other files which look like backups are left alone
+	// the backups stay where they were made when the file moves, the numbered ones are renamed next to it as they are shifted
+	if err = c.checkPaths(previous...); err != nil {
+		return nil, err
+	}
+
+	var newest string
+	var kept []string
+	if format == "timestamp" {
+		newest = path + "." + time.Now().UTC().Format(backupTimeFormat)
+		for _, backup := range previous[:min(count-1, len(previous))] {
+			if _, statErr := os.Lstat(backup); statErr == nil && backup != newest {
+				kept = append(kept, backup)
+			}
+		}
+	} else {
+		// shift the numbered backups up, oldest first so each rename replaces a backup which was already moved
+		for i := min(count-1, len(previous)); i >= 1; i-- {
+			next := fmt.Sprintf("%s.%d", path, i+1)
+			if err = notOtherFile(next, path, previous); err != nil {
+				return nil, err
+			}
+			if err = c.rename(previous[i-1], next); err != nil {
+				if os.IsNotExist(err) {
+					// the backup was removed outside of Terraform
+					continue
+				}
+				return nil, err
+			}
+			kept = append([]string{next}, kept...)
+		}
+		newest = path + ".1"
+	}
+	if err = c.Sandbox.Check(newest); err != nil {
+		return nil, err
+	}
+	if err = notOtherFile(newest, path, previous); err != nil {
+		return nil, err
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+	if err = c.write(newest, source, info.Mode()&fileModeBits); err != nil {
+		return nil, err
+	}
+	keepOwner(newest, info)
+
+	// the backups which weren't kept or replaced are over the count
+	for _, backup := range previous {
+		if backup == newest || slices.Contains(kept, backup) {
+			continue
+		}
+		if err = os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return append([]string{newest}, kept...), nil
+}
+
+// notOtherFile returns an error when a backup would replace a file which isn't one of the previous backups,
+// eg. 'app.log.1' written by a log rotation rather than the provider.
+func notOtherFile(backup string, path string, previous []string) error {
+	if _, err := os.Lstat(backup); err != nil || slices.Contains(previous, backup) {
+		return nil
+	}
+	return fmt.Errorf("can't back up '%s', '%s' already exists and isn't one of its backups, move it away or use the 'timestamp' suffix format", path, backup)
+}
+
+func (c *OsFileClient) checkPaths(paths ...string) error {
+	for _, path := range paths {
+		if err := c.Sandbox.Check(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write replaces the file at path atomically.
+// The data is written to a temporary file in the same directory, synced, then renamed over the target.
+// Readers only ever see the old file or the new file, and on failure the original file is left intact.
+func (c *OsFileClient) write(path string, data io.Reader, mode os.FileMode) (err error) {
+	directory := filepath.Dir(path)
+	tmp, err := os.CreateTemp(directory, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// The rename replaces the file, keep the owner and extended attributes of the file being replaced,
+	// the extended attributes include POSIX ACLs and the SELinux context.
+	// This is best effort, only a privileged user can give a file away or set some attributes.
+	if existing, statErr := os.Stat(path); statErr == nil {
+		owner := ownership.FromFileInfo(existing)
+		if uid, gid, resolveErr := ownership.Resolve(owner["Uid"], owner["Gid"]); resolveErr == nil && (uid != -1 || gid != -1) {
+			_ = tmp.Chown(uid, gid)
+		}
+		// an ACL changes the group bits of the mode, the chmod below gives the file the requested mode again
+		_ = xattr.Copy(path, tmp.Name())
+	}
+	if _, err = io.Copy(tmp, data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	// CreateTemp always uses 0600, chmod isn't affected by the umask so the file gets exactly the requested mode.
+	// This comes last because chown and writing from an unprivileged process both clear the setuid and setgid bits.
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if c.SyncDirectory {
+		return syncParent(directory)
+	}
+	return nil
+}
+
+// keepOwner gives the copy at path the owner of the original file,
+// this is best effort like write, only a privileged user can give a file away.
+func keepOwner(path string, original os.FileInfo) {
+	owner := ownership.FromFileInfo(original)
+	if uid, gid, err := ownership.Resolve(owner["Uid"], owner["Gid"]); err == nil && (uid != -1 || gid != -1) {
+		_ = ownership.Chown(path, uid, gid)
+	}
+}
+
+func syncDirectory(directory string) error {
+	d, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,375 @@
+package file_client
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
+)
+
+func TestOsFileClientBackup(t *testing.T) {
+	testCases := []struct {
+		name      string
+		format    string
+		other     string // a file next to the original which the provider didn't make
+		wantError string
+	}{
+		{"Numbered", "numbered", "app.log.7", ""},
+		{"Numbered replacing another file", "numbered", "app.log.1", "isn't one of its backups"},
+		{"Timestamp", "timestamp", "app.log.2024", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			directory := t.TempDir()
+			client := &OsFileClient{}
+			if err := os.WriteFile(filepath.Join(directory, "app.log"), []byte("this is version 1"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			other := filepath.Join(directory, tc.other)
+			if err := os.WriteFile(other, []byte("this isn't a backup"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			var backups []string
+			var err error
+			for version := 2; version <= 4; version++ {
+				backups, err = client.Backup(directory, "app.log", 2, tc.format, backups)
+				if err != nil {
+					break
+				}
+				if err = os.WriteFile(filepath.Join(directory, "app.log"), []byte("this is version "+strconv.Itoa(version)), 0600); err != nil {
+					t.Fatalf("Error setting up: %v", err)
+				}
+			}
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Errorf("Backup() error is %v; want an error containing %q", err, tc.wantError)
+				}
+			} else if err != nil {
+				t.Fatalf("Backup() error: %v", err)
+			}
+			if contents, readErr := os.ReadFile(other); readErr != nil || string(contents) != "this isn't a backup" {
+				t.Errorf("Backup() changed the other file to %q, %v", contents, readErr)
+			}
+			if tc.wantError != "" {
+				return
+			}
+			if len(backups) != 2 {
+				t.Fatalf("Backup() returned %v; want the 2 newest backups", backups)
+			}
+			for i, want := range []string{"this is version 3", "this is version 2"} {
+				if contents, readErr := os.ReadFile(backups[i]); readErr != nil || string(contents) != want {
+					t.Errorf("backup %s is %q, %v; want %q", backups[i], contents, readErr, want)
+				}
+			}
+			entries, err := os.ReadDir(directory)
+			if err != nil {
+				t.Fatalf("Error reading directory: %v", err)
+			}
+			for _, entry := range entries {
+				path := filepath.Join(directory, entry.Name())
+				if path != other && path != filepath.Join(directory, "app.log") && !slices.Contains(backups, path) {
+					t.Errorf("Backup() left %s behind", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+// TestOsFileClientCrossFilesystem makes every rename fail like it does between filesystems, so files are copied instead.
+func TestOsFileClientBackupMoved(t *testing.T) {
+	for _, format := range []string{"numbered", "timestamp"} {
+		t.Run(format, func(t *testing.T) {
+			oldDirectory := t.TempDir()
+			newDirectory := t.TempDir()
+			client := &OsFileClient{}
+			if err := os.WriteFile(filepath.Join(oldDirectory, "app.log"), []byte("this is version 1"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			var backups []string
+			var err error
+			for range 2 {
+				if backups, err = client.Backup(oldDirectory, "app.log", 2, format, backups); err != nil {
+					t.Fatalf("Backup() error: %v", err)
+				}
+			}
+			// the file moves, the backups made at the old path are still recorded
+			if err := os.Rename(filepath.Join(oldDirectory, "app.log"), filepath.Join(newDirectory, "moved.log")); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			if backups, err = client.Backup(newDirectory, "moved.log", 2, format, backups); err != nil {
+				t.Fatalf("Backup() error: %v", err)
+			}
+			if len(backups) != 2 || filepath.Dir(backups[0]) != newDirectory {
+				t.Fatalf("Backup() returned %v; want the new backup and the newest old one", backups)
+			}
+			// nothing is left behind untracked, numbered backups are renamed next to the file
+			for _, directory := range []string{oldDirectory, newDirectory} {
+				entries, err := os.ReadDir(directory)
+				if err != nil {
+					t.Fatalf("Error reading directory: %v", err)
+				}
+				for _, entry := range entries {
+					path := filepath.Join(directory, entry.Name())
+					if path != filepath.Join(newDirectory, "moved.log") && !slices.Contains(backups, path) {
+						t.Errorf("Backup() left %s untracked", path)
+					}
+				}
+			}
+			if format == "numbered" && !slices.Equal(backups, []string{filepath.Join(newDirectory, "moved.log.1"), filepath.Join(newDirectory, "moved.log.2")}) {
+				t.Errorf("Backup() returned %v; want both backups next to the moved file", backups)
+			}
+		})
+	}
+}
+
+func TestOsFileClientCrossFilesystem(t *testing.T) {
+	testCases := []struct {
+		name      string
+		move      func(c *OsFileClient, from string, to string) error
+		want      string
+		wantError bool
+	}{
+		{
+			"Update",
+			func(c *OsFileClient, from string, to string) error {
+				return c.Update(filepath.Dir(from), filepath.Base(from), filepath.Dir(to), filepath.Base(to), "this is an updated move test", "0640")
+			},
+			"this is an updated move test",
+			false,
+		},
+		{
+			"Move",
+			func(c *OsFileClient, from string, to string) error {
+				return c.Move(filepath.Dir(from), filepath.Base(from), filepath.Dir(to), filepath.Base(to), "0640")
+			},
+			"this is a move test",
+			false,
+		},
+		{
+			"Missing directory",
+			func(c *OsFileClient, from string, to string) error {
+				return c.Move(filepath.Dir(from), filepath.Base(from), filepath.Join(filepath.Dir(to), "missing"), filepath.Base(to), "0640")
+			},
+			"",
+			true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from := filepath.Join(t.TempDir(), "from.txt")
+			to := filepath.Join(t.TempDir(), "to.txt")
+			if err := os.WriteFile(from, []byte("this is a move test"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			if err := os.Chmod(from, 0640); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			if os.Geteuid() == 0 {
+				// only root can give the file away, otherwise the owner is the user running the test
+				if err := ownership.Chown(from, 1234, 5678); err != nil {
+					t.Fatalf("Error setting up: %v", err)
+				}
+			}
+			original, err := os.Stat(from)
+			if err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+
+			renameFile = func(oldPath string, newPath string) error {
+				return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: syscall.EXDEV}
+			}
+			removeFile = func(path string) error {
+				// the copy has been synced and renamed into place, only the temporary file was written before
+				if contents, err := os.ReadFile(to); err != nil || string(contents) != "this is a move test" {
+					t.Errorf("the original was removed before it was copied, the copy is %q, %v", contents, err)
+				}
+				if temporary, _ := filepath.Glob(filepath.Join(filepath.Dir(to), ".to.txt.tmp-*")); len(temporary) > 0 {
+					t.Errorf("the original was removed while the copy was still being written to %v", temporary)
+				}
+				return os.Remove(path)
+			}
+			t.Cleanup(func() {
+				renameFile = os.Rename
+				removeFile = os.Remove
+			})
+
+			err = tc.move(&OsFileClient{}, from, to)
+			if tc.wantError {
+				if err == nil {
+					t.Fatalf("moving to a missing directory succeeded")
+				}
+				if contents, readErr := os.ReadFile(from); readErr != nil || string(contents) != "this is a move test" {
+					t.Errorf("the original is %q, %v after the copy failed; want it unchanged", contents, readErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if _, err = os.Stat(from); !os.IsNotExist(err) {
+				t.Errorf("the original is still there: %v", err)
+			}
+			info, err := os.Stat(to)
+			if err != nil {
+				t.Fatalf("Error reading the copy: %v", err)
+			}
+			if contents, err := os.ReadFile(to); err != nil || string(contents) != tc.want {
+				t.Errorf("the copy is %q, %v; want %q", contents, err, tc.want)
+			}
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("the copy has mode %s; want %s", info.Mode().Perm(), original.Mode().Perm())
+			}
+			got, want := ownership.FromFileInfo(info), ownership.FromFileInfo(original)
+			if got["Uid"] != want["Uid"] || got["Gid"] != want["Gid"] {
+				t.Errorf("the copy is owned by %s:%s; want %s:%s", got["Uid"], got["Gid"], want["Uid"], want["Gid"])
+			}
+		})
+	}
+}
+
+// failingReader returns some data, then fails like a source which can't be read to the end.
+type failingReader struct {
+	read bool
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.read {
+		return 0, errors.New("this is a failing read")
+	}
+	f.read = true
+	return copy(p, "this is a partial"), nil
+}
+
+func TestOsFileClientWrite(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          io.Reader
+		syncDirectory bool
+		want          string
+		wantError     bool
+	}{
+		{"Write", strings.NewReader("this is a write test"), false, "this is a write test", false},
+		{"Sync directory", strings.NewReader("this is a write test"), true, "this is a write test", false},
+		{"Failed write", &failingReader{}, true, "this is the original", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			directory := t.TempDir()
+			path := filepath.Join(directory, "write.txt")
+			if err := os.WriteFile(path, []byte("this is the original"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			var synced []string
+			syncParent = func(directory string) error {
+				synced = append(synced, directory)
+				return syncDirectory(directory)
+			}
+			t.Cleanup(func() { syncParent = syncDirectory })
+
+			c := &OsFileClient{SyncDirectory: tc.syncDirectory}
+			err := c.write(path, tc.data, 0640)
+			if (err != nil) != tc.wantError {
+				t.Fatalf("write() error is %v; want error: %t", err, tc.wantError)
+			}
+			if contents, err := os.ReadFile(path); err != nil || string(contents) != tc.want {
+				t.Errorf("the file is %q, %v; want %q", contents, err, tc.want)
+			}
+			if temporary, _ := filepath.Glob(filepath.Join(directory, ".write.txt.tmp-*")); len(temporary) > 0 {
+				t.Errorf("write() left the temporary files %v", temporary)
+			}
+			// the directory is synced after the rename, which a failed write never gets to
+			wantSynced := []string(nil)
+			if tc.syncDirectory && !tc.wantError {
+				wantSynced = []string{directory}
+			}
+			if !slices.Equal(synced, wantSynced) {
+				t.Errorf("write() synced %v; want %v", synced, wantSynced)
+			}
+		})
+	}
+}
+
+// TestOsFileClientWriteSymlink checks what the resource documents, the write replaces a symlink rather than writing through it.
+func TestOsFileClientWriteSymlink(t *testing.T) {
+	directory := t.TempDir()
+	target := filepath.Join(directory, "target.txt")
+	link := filepath.Join(directory, "link.txt")
+	if err := os.WriteFile(target, []byte("this is the target"), 0600); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks aren't available: %v", err)
+	}
+	if err := (&OsFileClient{}).write(link, strings.NewReader("this is a symlink test"), 0600); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("the link is %v, %v after the write; want a regular file", info, err)
+	}
+	if contents, err := os.ReadFile(target); err != nil || string(contents) != "this is the target" {
+		t.Errorf("the target is %q, %v; want it unchanged", contents, err)
+	}
+}
+
+func TestOsFileClientMakeDirectorySandbox(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "srv", "app")
+	s, err := sandbox.New([]string{allowed}, nil)
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	client := &OsFileClient{Sandbox: s}
+
+	// the missing parent 'srv' is outside of the allowed path
+	if created, err := client.MakeDirectory(filepath.Join(allowed, "conf"), "0755"); err == nil || !strings.Contains(err.Error(), "outside of the allowed paths") {
+		t.Errorf("MakeDirectory() is %v, %v; want an error for the parent outside of the sandbox", created, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "srv")); !os.IsNotExist(err) {
+		t.Errorf("MakeDirectory() created a directory outside of the sandbox: %v", err)
+	}
+
+	if err := os.MkdirAll(allowed, 0755); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	created, err := client.MakeDirectory(filepath.Join(allowed, "conf", "d"), "0755")
+	if err != nil {
+		t.Fatalf("MakeDirectory() error: %v", err)
+	}
+	want := []string{filepath.Join(allowed, "conf"), filepath.Join(allowed, "conf", "d")}
+	if !slices.Equal(created, want) {
+		t.Errorf("MakeDirectory() created %v; want %v", created, want)
+	}
+}
+
+func TestOsFileClientWriteXattrs(t *testing.T) {
+	directory := t.TempDir()
+	client := &OsFileClient{}
+	if err := client.Create(directory, "xattrs.txt", "first", "0644"); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	path := filepath.Join(directory, "xattrs.txt")
+	if err := xattr.Set(path, map[string]string{"user.unmanaged": "keep me"}); err != nil {
+		t.Skipf("the filesystem doesn't support user extended attributes: %v", err)
+	}
+
+	// the temporary file replaces the file, it gets the attributes of the file it replaces
+	if err := client.Update(directory, "xattrs.txt", directory, "xattrs.txt", "second", "0644"); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	attributes, err := xattr.List(path)
+	if err != nil {
+		t.Fatalf("Error reading attributes: %v", err)
+	}
+	if attributes["user.unmanaged"] != "keep me" {
+		t.Errorf("Update() left the attributes %v; want user.unmanaged kept", attributes)
+	}
+}
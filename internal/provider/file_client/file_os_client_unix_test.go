@@ -0,0 +1,47 @@
+//go:build !windows
+
+package file_client
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestOsFileClientWriteMode checks the file gets exactly the requested mode,
+// writing from an unprivileged process clears the setuid and setgid bits so the chmod has to come after the data.
+func TestOsFileClientWriteMode(t *testing.T) {
+	testCases := []struct {
+		name  string
+		perms string
+		umask int
+		want  os.FileMode
+	}{
+		{"Setuid", "4755", 0022, 0755 | os.ModeSetuid},
+		{"Setgid", "2755", 0022, 0755 | os.ModeSetgid},
+		{"Restrictive umask", "0644", 0077, 0644},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := syscall.Umask(tc.umask)
+			t.Cleanup(func() { syscall.Umask(previous) })
+
+			directory := t.TempDir()
+			client := &OsFileClient{}
+			// Create writes a new file, Update replaces an existing one
+			if err := client.Create(directory, "mode.txt", "this is a mode test", tc.perms); err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+			if info, err := os.Stat(filepath.Join(directory, "mode.txt")); err != nil || info.Mode() != tc.want {
+				t.Errorf("Create() mode is %v, %v; want %v", info.Mode(), err, tc.want)
+			}
+			if err := client.Update(directory, "mode.txt", directory, "mode.txt", "this is a changed mode test", tc.perms); err != nil {
+				t.Fatalf("Update() error: %v", err)
+			}
+			if info, err := os.Stat(filepath.Join(directory, "mode.txt")); err != nil || info.Mode() != tc.want {
+				t.Errorf("Update() mode is %v, %v; want %v", info.Mode(), err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package file_client
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime isn't available everywhere, the modification time stands in for it.
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
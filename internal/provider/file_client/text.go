@@ -0,0 +1,150 @@
+package file_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	utf8BOM    = "\xef\xbb\xbf"
+	utf16LEBOM = "\xff\xfe"
+)
+
+// TextFileClient converts the line endings and encoding of the contents as they are written,
+// and converts them back as they are read, so the contents compare to the text which was written.
+// Line endings are "lf", "crlf", or "preserve", encodings are "utf-8", "utf-8-bom", "utf-16le" (with a BOM), or "latin1".
+// Only Create, Update, Read, Open, and Hash convert the contents, everything else works on the file as it is.
+type TextFileClient struct {
+	FileClient
+	LineEndings string
+	Encoding    string
+}
+
+var _ FileClient = &TextFileClient{} // make sure the TextFileClient implements the FileClient
+
+func (c *TextFileClient) Create(directory string, name string, data string, permissions string) error {
+	encoded, err := EncodeText(data, c.LineEndings, c.Encoding)
+	if err != nil {
+		return err
+	}
+	return c.FileClient.Create(directory, name, encoded, permissions)
+}
+
+func (c *TextFileClient) Update(currentDirectory string, currentName string, newDirectory string, newName string, data string, permissions string) error {
+	encoded, err := EncodeText(data, c.LineEndings, c.Encoding)
+	if err != nil {
+		return err
+	}
+	return c.FileClient.Update(currentDirectory, currentName, newDirectory, newName, encoded, permissions)
+}
+
+func (c *TextFileClient) Read(directory string, name string) (string, string, error) {
+	permissions, data, err := c.FileClient.Read(directory, name)
+	if err != nil {
+		return "", "", err
+	}
+	decoded, err := DecodeText(data, c.LineEndings, c.Encoding)
+	if err != nil {
+		return "", "", fmt.Errorf("problem decoding '%s': %w", name, err)
+	}
+	return permissions, decoded, nil
+}
+
+// Open reads the whole file to decode it, text files are expected to fit in memory.
+func (c *TextFileClient) Open(directory string, name string) (io.ReadCloser, error) {
+	_, decoded, err := c.Read(directory, name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(decoded)), nil
+}
+
+// Hash returns the hash of the decoded contents.
+func (c *TextFileClient) Hash(directory string, name string) (string, error) {
+	_, decoded, err := c.Read(directory, name)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(decoded))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// EncodeText converts the line endings of the text, then encodes it.
+// "lf" and "crlf" replace every line ending, so text with mixed line endings is written consistently.
+func EncodeText(text string, lineEndings string, encoding string) (string, error) {
+	switch lineEndings {
+	case "lf":
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	case "crlf":
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	case "", "preserve":
+	default:
+		return "", fmt.Errorf("unknown line endings '%s'", lineEndings)
+	}
+	switch encoding {
+	case "", "utf-8":
+		return text, nil
+	case "utf-8-bom":
+		return utf8BOM + text, nil
+	case "utf-16le":
+		units := utf16.Encode([]rune(text))
+		encoded := make([]byte, 0, len(utf16LEBOM)+2*len(units))
+		encoded = append(encoded, utf16LEBOM...)
+		for _, unit := range units {
+			encoded = append(encoded, byte(unit), byte(unit>>8))
+		}
+		return string(encoded), nil
+	case "latin1":
+		encoded := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xff {
+				return "", fmt.Errorf("the character '%c' can't be encoded in latin1", r)
+			}
+			encoded = append(encoded, byte(r))
+		}
+		return string(encoded), nil
+	default:
+		return "", fmt.Errorf("unknown encoding '%s'", encoding)
+	}
+}
+
+// DecodeText reverses EncodeText, a missing BOM is accepted and "crlf" line endings are read back as "\n".
+func DecodeText(data string, lineEndings string, encoding string) (string, error) {
+	var text string
+	switch encoding {
+	case "", "utf-8":
+		text = data
+	case "utf-8-bom":
+		text = strings.TrimPrefix(data, utf8BOM)
+	case "utf-16le":
+		data = strings.TrimPrefix(data, utf16LEBOM)
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("utf-16le data has an odd number of bytes")
+		}
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i < len(data); i += 2 {
+			units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+		}
+		text = string(utf16.Decode(units))
+	case "latin1":
+		runes := make([]rune, 0, len(data))
+		for i := 0; i < len(data); i++ {
+			runes = append(runes, rune(data[i]))
+		}
+		text = string(runes)
+	default:
+		return "", fmt.Errorf("unknown encoding '%s'", encoding)
+	}
+	switch lineEndings {
+	case "crlf":
+		return strings.ReplaceAll(text, "\r\n", "\n"), nil
+	case "", "lf", "preserve":
+		return text, nil
+	default:
+		return "", fmt.Errorf("unknown line endings '%s'", lineEndings)
+	}
+}
@@ -0,0 +1,91 @@
+package file_client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeText(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lineEndings string
+		encoding    string
+		text        string
+		want        string
+		wantError   string
+	}{
+		{"Preserve", "preserve", "utf-8", "one\r\ntwo\nthree", "one\r\ntwo\nthree", ""},
+		{"Default", "", "", "one\r\ntwo\n", "one\r\ntwo\n", ""},
+		{"LF", "lf", "utf-8", "one\r\ntwo\nthree\r\n", "one\ntwo\nthree\n", ""},
+		{"CRLF", "crlf", "utf-8", "one\r\ntwo\nthree", "one\r\ntwo\r\nthree", ""},
+		{"Lone CR", "lf", "utf-8", "one\rtwo\r\n", "one\rtwo\n", ""},
+		{"UTF-8 BOM", "preserve", "utf-8-bom", "café", "\xef\xbb\xbfcaf\xc3\xa9", ""},
+		{"UTF-16LE", "crlf", "utf-16le", "hé\n", "\xff\xfeh\x00\xe9\x00\r\x00\n\x00", ""},
+		{"UTF-16LE surrogate pair", "preserve", "utf-16le", "\U0001f600", "\xff\xfe\x3d\xd8\x00\xde", ""},
+		{"Latin1", "preserve", "latin1", "café", "caf\xe9", ""},
+		{"Latin1 can't encode", "preserve", "latin1", "5€", "", "can't be encoded in latin1"},
+		{"Unknown line endings", "cr", "utf-8", "one", "", "unknown line endings"},
+		{"Unknown encoding", "lf", "ebcdic", "one", "", "unknown encoding"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeText(tc.text, tc.lineEndings, tc.encoding)
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Errorf("EncodeText() error is %v; want an error containing %q", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncodeText() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("EncodeText() is %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lineEndings string
+		encoding    string
+		data        string
+		want        string
+		wantError   string
+	}{
+		{"Preserve", "preserve", "utf-8", "one\r\ntwo\n", "one\r\ntwo\n", ""},
+		{"LF", "lf", "utf-8", "one\r\ntwo\n", "one\r\ntwo\n", ""},
+		{"CRLF", "crlf", "utf-8", "one\r\ntwo\r\n", "one\ntwo\n", ""},
+		{"UTF-8 BOM", "preserve", "utf-8-bom", "\xef\xbb\xbfcaf\xc3\xa9", "café", ""},
+		{"UTF-8 BOM missing", "preserve", "utf-8-bom", "caf\xc3\xa9", "café", ""},
+		{"UTF-8 BOM kept in utf-8", "preserve", "utf-8", "\xef\xbb\xbfcaf\xc3\xa9", "\ufeffcafé", ""},
+		{"UTF-16LE", "crlf", "utf-16le", "\xff\xfeh\x00\xe9\x00\r\x00\n\x00", "hé\n", ""},
+		{"UTF-16LE BOM missing", "preserve", "utf-16le", "h\x00i\x00", "hi", ""},
+		{"UTF-16LE surrogate pair", "preserve", "utf-16le", "\xff\xfe\x3d\xd8\x00\xde", "\U0001f600", ""},
+		{"UTF-16LE unpaired surrogate", "preserve", "utf-16le", "\xff\xfe\x3d\xd8", "�", ""},
+		{"UTF-16LE odd bytes", "preserve", "utf-16le", "\xff\xfeh\x00i", "", "odd number of bytes"},
+		{"Latin1", "preserve", "latin1", "caf\xe9", "café", ""},
+		{"Latin1 every byte is valid", "preserve", "latin1", "\x00\x80\xff", "\u0000\u0080ÿ", ""},
+		{"Unknown encoding", "lf", "ebcdic", "one", "", "unknown encoding"},
+		{"Unknown line endings", "cr", "utf-8", "one", "", "unknown line endings"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeText(tc.data, tc.lineEndings, tc.encoding)
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Errorf("DecodeText() error is %v; want an error containing %q", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeText() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("DecodeText() is %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -4,14 +4,24 @@ package file_local
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
+	"unicode/utf8"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
 	c "github.com/rancher/terraform-provider-file/internal/provider/file_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -26,16 +36,34 @@ func NewLocalDataSource() datasource.DataSource {
 }
 
 type LocalDataSource struct {
-	client c.FileClient
+	client       c.FileClient
+	providerData *provider_data.ProviderData
 }
 
 type LocalDataSourceModel struct {
-	ID            types.String `tfsdk:"id"`
-	Name          types.String `tfsdk:"name"`
-	Directory     types.String `tfsdk:"directory"`
-	Contents      types.String `tfsdk:"contents"`
-	Permissions   types.String `tfsdk:"permissions"`
-	HmacSecretKey types.String `tfsdk:"hmac_secret_key"`
+	ID             types.String `tfsdk:"id"`
+	Name           types.String `tfsdk:"name"`
+	Directory      types.String `tfsdk:"directory"`
+	Contents       types.String `tfsdk:"contents"`
+	ContentsBase64 types.String `tfsdk:"contents_base64"`
+	Permissions    types.String `tfsdk:"permissions"`
+	Owner          types.String `tfsdk:"owner"`
+	Group          types.String `tfsdk:"group"`
+	HmacSecretKey  types.String `tfsdk:"hmac_secret_key"`
+	HmacAlgorithm  types.String `tfsdk:"hmac_algorithm"`
+	ModifiedTime   types.String `tfsdk:"modified_time"`
+	AccessTime     types.String `tfsdk:"access_time"`
+	Xattrs         types.Map    `tfsdk:"xattrs"`
+	SelinuxContext types.String `tfsdk:"selinux_context"`
+	Exists         types.Bool   `tfsdk:"exists"`
+	FailIfMissing  types.Bool   `tfsdk:"fail_if_missing"`
+	// Encryption is nil when the block isn't given, the file is read as it is.
+	Encryption *LocalDataSourceEncryptionModel `tfsdk:"encryption"`
+}
+
+type LocalDataSourceEncryptionModel struct {
+	Key      types.String `tfsdk:"key"`
+	Identity types.String `tfsdk:"identity"`
 }
 
 func (r *LocalDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -63,28 +91,122 @@ func (r *LocalDataSource) Schema(_ context.Context, _ datasource.SchemaRequest,
 				Computed:  true,
 				Sensitive: true,
 			},
+			"hmac_algorithm": schema.StringAttribute{
+				MarkdownDescription: "The hash algorithm used for the HMAC which calculates the id, one of " +
+					"'sha256', 'sha384', 'sha512', or 'blake2b-256', defaults to 'sha256'.",
+				Optional: true,
+				Computed: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(hmacAlgorithmNames()...),
+				},
+			},
 			"contents": schema.StringAttribute{
 				MarkdownDescription: "The file contents.",
 				Computed:            true,
 				Sensitive:           true,
 			},
+			"contents_base64": schema.StringAttribute{
+				MarkdownDescription: "The file contents encoded in base64, use this to read binary files.",
+				Computed:            true,
+				Sensitive:           true,
+			},
 			"permissions": schema.StringAttribute{
 				MarkdownDescription: "The file permissions.",
 				Computed:            true,
 			},
+			"owner": schema.StringAttribute{
+				MarkdownDescription: "The user which owns the file, the numeric id is given when it doesn't resolve to a name.",
+				Computed:            true,
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "The group which owns the file, the numeric id is given when it doesn't resolve to a name.",
+				Computed:            true,
+			},
+			"modified_time": schema.StringAttribute{
+				MarkdownDescription: "The modification time of the file in RFC 3339 format.",
+				Computed:            true,
+			},
+			"access_time": schema.StringAttribute{
+				MarkdownDescription: "The access time of the file in RFC 3339 format, from before the file was read by this data source.",
+				Computed:            true,
+			},
+			"xattrs": schema.MapAttribute{
+				MarkdownDescription: "Every extended attribute of the file, including the SELinux context. " +
+					"This is empty when the filesystem doesn't support extended attributes.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"selinux_context": schema.StringAttribute{
+				MarkdownDescription: "The SELinux context of the file, null when the file doesn't have one.",
+				Computed:            true,
+			},
 			"id": schema.StringAttribute{
-				MarkdownDescription: "Identifier derived from sha256+HMAC hash of file contents. ",
+				MarkdownDescription: "Identifier derived from HMAC hash of file contents, see 'hmac_algorithm'. ",
 				Computed:            true,
 			},
+			"exists": schema.BoolAttribute{
+				MarkdownDescription: "Whether the file exists, this is only false when 'fail_if_missing' is false. " +
+					"When the file doesn't exist every attribute read from the file is null.",
+				Computed: true,
+			},
+			"fail_if_missing": schema.BoolAttribute{
+				MarkdownDescription: "Whether a missing file is an error naming its path, defaults to true. " +
+					"Set this to false to check 'exists' instead.",
+				Optional: true,
+				Computed: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"encryption": schema.SingleNestedBlock{
+				MarkdownDescription: "Decrypt a file written by a file_local resource with an encryption block, " +
+					"the contents and id are calculated from the plaintext. Reading a file which isn't encrypted is an error.",
+				Attributes: map[string]schema.Attribute{
+					"key": schema.StringAttribute{
+						MarkdownDescription: "The base64 encoded AES-256 key, for files encrypted without recipients. " +
+							"Defaults to the provider's 'encryption_key', then the `TF_FILE_ENCRYPTION_KEY` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+						Validators: []validator.String{
+							encryption.KeyValidator(),
+						},
+					},
+					"identity": schema.StringAttribute{
+						MarkdownDescription: "The age identity of one of the recipients, eg. 'AGE-SECRET-KEY-1...', the contents of an identity file are accepted. " +
+							"Defaults to the provider's 'age_identity', then the `TF_FILE_AGE_IDENTITY` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+			},
 		},
 	}
 }
 
-func (r *LocalDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+func (r *LocalDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
+	data, ok := req.ProviderData.(*provider_data.ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider_data.ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	r.providerData = data
+	if client, ok := r.client.(*c.OsFileClient); ok {
+		client.Sandbox = data.Sandbox
+	}
+}
+
+// timeInfo returns a time from the file info map, null when the client doesn't know it.
+func timeInfo(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
 }
 
 // Read runs before all other resources are run, datasources only get the Read function.
@@ -101,11 +223,19 @@ func (r *LocalDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 	cDirectory := config.Directory.ValueString()
 	if cDirectory == "" {
 		cDirectory = "."
+		if r.providerData != nil && r.providerData.RootDirectory != "" {
+			cDirectory = r.providerData.RootDirectory
+		}
+		config.Directory = types.StringValue(cDirectory)
 	}
 	cPerm := config.Permissions.ValueString()
 	cHmacSecretKey := config.HmacSecretKey.ValueString()
 
 	cKey := cHmacSecretKey
+	if cKey == "" && r.providerData != nil && r.providerData.HmacSecretKey != "" {
+		tflog.Debug(ctx, "Using secret key from provider configuration.")
+		cKey = r.providerData.HmacSecretKey
+	}
 	if cKey == "" {
 		tflog.Debug(ctx, "Checking for secret key in environment variable TF_FILE_HMAC_SECRET_KEY.")
 		cKey = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
@@ -116,19 +246,63 @@ func (r *LocalDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 		cKey = unprotectedHmacSecret // this is a constant defined in file_local_resource.go
 	}
 
-	perm, contents, err := r.client.Read(cDirectory, cName)
+	if config.FailIfMissing.IsNull() {
+		config.FailIfMissing = types.BoolValue(true)
+	}
+	config.HmacAlgorithm = types.StringValue(hmacAlgorithmName(config.HmacAlgorithm.ValueString()))
+
+	// stat the file before reading it, so the access time isn't this read
+	info, err := r.client.Info(cDirectory, cName)
 	if err != nil && err.Error() == "file not found" {
-		resp.State.RemoveResource(ctx)
+		if config.FailIfMissing.ValueBool() {
+			resp.Diagnostics.AddError("Error reading file: ",
+				fmt.Sprintf("the file '%s' doesn't exist, set 'fail_if_missing' to false to read a file which may be missing",
+					filepath.Join(cDirectory, cName)))
+			return
+		}
+		config.Exists = types.BoolValue(false)
+		config.ID = types.StringNull()
+		config.Contents = types.StringNull()
+		config.ContentsBase64 = types.StringNull()
+		config.Permissions = types.StringNull()
+		config.Owner = types.StringNull()
+		config.Group = types.StringNull()
+		config.ModifiedTime = types.StringNull()
+		config.AccessTime = types.StringNull()
+		config.Xattrs = types.MapNull(types.StringType)
+		config.SelinuxContext = types.StringNull()
+		resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
 		return
 	}
+	client := r.client
+	if config.Encryption != nil {
+		client = &c.EncryptedFileClient{
+			FileClient: r.client,
+			Key:        encryptionKey(r.providerData, config.Encryption.Key.ValueString()),
+			Identity:   ageIdentity(r.providerData, config.Encryption.Identity.ValueString()),
+		}
+	}
+	perm, contents, err := client.Read(cDirectory, cName)
 	if err != nil {
 		resp.Diagnostics.AddError("Error reading file: ", err.Error())
 		return
 	}
 
 	// update state with actual contents
-	config.Contents = types.StringValue(contents)
-	id, err := calculateID(contents, cKey)
+	// Terraform strings must be valid UTF-8, binary files are only available in contents_base64
+	if utf8.ValidString(contents) {
+		config.Contents = types.StringValue(contents)
+	} else {
+		tflog.Debug(ctx, "File contents aren't valid UTF-8, leaving contents empty.")
+		config.Contents = types.StringNull()
+	}
+	config.ContentsBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(contents)))
+	config.Exists = types.BoolValue(true)
+	id, err := calculateID(contents, cKey, config.HmacAlgorithm.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Error reading file: ", "Problem calculating id from key: "+err.Error())
 		return
@@ -140,6 +314,21 @@ func (r *LocalDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 		config.Permissions = types.StringValue(perm)
 	}
 
+	config.Owner = ownership.Value(info["Owner"], info["Uid"])
+	config.Group = ownership.Value(info["Group"], info["Gid"])
+	config.ModifiedTime = timeInfo(info["ModifiedTime"])
+	config.AccessTime = timeInfo(info["AccessTime"])
+
+	xattrs, err := r.client.Xattrs(cDirectory, cName)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading extended attributes: ", err.Error())
+		return
+	}
+	var diags diag.Diagnostics
+	config.Xattrs, diags = xattr.Value(ctx, xattrs)
+	resp.Diagnostics.Append(diags...)
+	config.SelinuxContext = xattr.ContextValue(xattrs)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
 	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
 }
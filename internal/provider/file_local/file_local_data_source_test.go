@@ -6,13 +6,18 @@ import (
 	"context"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
 	c "github.com/rancher/terraform-provider-file/internal/provider/file_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
 )
 
 func TestLocalDataSourceMetadata(t *testing.T) {
@@ -65,7 +70,9 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"directory":       defaultDirectory,
 					"permissions":     defaultPerm,
 					"contents":        "this is an unprotected read test",
+					"contents_base64": "dGhpcyBpcyBhbiB1bnByb3RlY3RlZCByZWFkIHRlc3Q=",
 					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
 				}),
 				// setup
 				map[string]string{
@@ -75,6 +82,35 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"contents":  "this is an unprotected read test",
 				},
 			},
+			{
+				"Unprotected sha384",
+				LocalDataSource{client: &c.MemoryFileClient{}},
+				// have
+				getDataSourceReadRequest(t, map[string]string{
+					"name":            "read_sha384.tmp",
+					"directory":       defaultDirectory,
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha384",
+				}),
+				// want
+				getDataSourceReadResponse(t, map[string]string{
+					"id":              "96b0b6f1640762a344afb99cd92c7a1d771d3d23c2db3a8d7ae9e9057783ec06f5c83b3c60f778fbd49a45637c36c803",
+					"name":            "read_sha384.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is an unprotected read test",
+					"contents_base64": "dGhpcyBpcyBhbiB1bnByb3RlY3RlZCByZWFkIHRlc3Q=",
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha384",
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_sha384.tmp",
+					"contents":  "this is an unprotected read test",
+				},
+			},
 			{
 				"Protected",
 				LocalDataSource{client: &c.MemoryFileClient{}},
@@ -94,7 +130,9 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"directory":       defaultDirectory,
 					"permissions":     defaultPerm,
 					"contents":        "this is a protected read test",
+					"contents_base64": "dGhpcyBpcyBhIHByb3RlY3RlZCByZWFkIHRlc3Q=",
 					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
 				}),
 				// reality
 				map[string]string{
@@ -123,7 +161,9 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"directory":       defaultDirectory,
 					"permissions":     defaultPerm,
 					"contents":        "this is a change in contents in the real file",
+					"contents_base64": "dGhpcyBpcyBhIGNoYW5nZSBpbiBjb250ZW50cyBpbiB0aGUgcmVhbCBmaWxl",
 					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
 				}),
 				// reality
 				map[string]string{
@@ -133,6 +173,33 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"contents":  "this is a change in contents in the real file",
 				},
 			},
+			{
+				"Binary contents",
+				LocalDataSource{client: &c.MemoryFileClient{}},
+				// have
+				getDataSourceReadRequest(t, map[string]string{
+					"name":            "read_binary.tmp",
+					"directory":       defaultDirectory,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getDataSourceReadResponse(t, map[string]string{
+					"id":              "897871ed26a340a5edef5e0e0f3474e81e124a71b06387b048d9e4d7c8a6c169",
+					"name":            "read_binary.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_base64": "H4sIAP8=",
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_binary.tmp",
+					"contents":  "\x1f\x8b\x08\x00\xff",
+				},
+			},
 			{
 				"Protected with mode update",
 				LocalDataSource{client: &c.MemoryFileClient{}},
@@ -152,7 +219,9 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"directory":       defaultDirectory,
 					"permissions":     "0755",
 					"contents":        "this is a protected read test",
+					"contents_base64": "dGhpcyBpcyBhIHByb3RlY3RlZCByZWFkIHRlc3Q=",
 					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
 				}),
 				// reality
 				map[string]string{
@@ -162,12 +231,82 @@ func TestLocalDataSourceRead(t *testing.T) {
 					"contents":  "this is a protected read test",
 				},
 			},
+			{
+				"Owner and group",
+				LocalDataSource{client: &c.MemoryFileClient{}},
+				// have
+				getDataSourceReadRequest(t, map[string]string{
+					"name":      "read_owner.tmp",
+					"directory": defaultDirectory,
+				}),
+				// want
+				getDataSourceReadResponse(t, map[string]string{
+					"id":              "60cef95046105ff4522c0c1f1aeeeba43d0d729dbcabdd8846c317c98cac60a2",
+					"name":            "read_owner.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"owner":           "nobody",
+					"group":           "1000",
+					"contents":        "this is an unprotected read test",
+					"contents_base64": "dGhpcyBpcyBhbiB1bnByb3RlY3RlZCByZWFkIHRlc3Q=",
+					"hmac_algorithm":  "sha256",
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"owner":     "nobody",
+					"group":     "1000",
+					"directory": defaultDirectory,
+					"name":      "read_owner.tmp",
+					"contents":  "this is an unprotected read test",
+				},
+			},
+			{
+				"SELinux context",
+				LocalDataSource{client: &c.MemoryFileClient{}},
+				// have
+				getDataSourceReadRequest(t, map[string]string{
+					"name":      "read_selinux.tmp",
+					"directory": defaultDirectory,
+				}),
+				// want
+				func() datasource.ReadResponse {
+					resp := getDataSourceReadResponse(t, map[string]string{
+						"id":              "60cef95046105ff4522c0c1f1aeeeba43d0d729dbcabdd8846c317c98cac60a2",
+						"name":            "read_selinux.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is an unprotected read test",
+						"contents_base64": "dGhpcyBpcyBhbiB1bnByb3RlY3RlZCByZWFkIHRlc3Q=",
+						"hmac_algorithm":  "sha256",
+						"selinux_context": "system_u:object_r:etc_t:s0",
+					})
+					resp.State.SetAttribute(context.Background(), path.Root("xattrs"), map[string]string{"security.selinux": "system_u:object_r:etc_t:s0"})
+					return resp
+				}(),
+				// setup
+				map[string]string{
+					"mode":            defaultPerm,
+					"directory":       defaultDirectory,
+					"name":            "read_selinux.tmp",
+					"contents":        "this is an unprotected read test",
+					"selinux_context": "system_u:object_r:etc_t:s0",
+				},
+			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
 					t.Errorf("Error setting up: %v", err)
 				}
+				if err := tc.fit.client.Chown(tc.setup["directory"], tc.setup["name"], tc.setup["owner"], tc.setup["group"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				if label := tc.setup["selinux_context"]; label != "" {
+					if err := tc.fit.client.SetXattrs(tc.setup["directory"], tc.setup["name"], map[string]string{"security.selinux": label}, nil); err != nil {
+						t.Errorf("Error setting up: %v", err)
+					}
+				}
 				defer func() {
 					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
 						t.Errorf("Error tearing down: %v", err)
@@ -199,7 +338,7 @@ func getDataSourceReadRequest(t *testing.T, data map[string]string) datasource.R
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getDataSourceObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getDataSourceObjectAttributeTypes(), fillNulls(getDataSourceObjectAttributeTypes(), stateMap))
 	return datasource.ReadRequest{
 		Config: tfsdk.Config{
 			Raw:    stateValue,
@@ -227,7 +366,16 @@ func getDataSourceReadResponse(t *testing.T, data map[string]string) datasource.
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getDataSourceObjectAttributeTypes(), stateMap)
+	// the data source always reads the extended attributes, a file without any has an empty map
+	stateMap["xattrs"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{})
+	// the file exists unless the test says otherwise, and a missing file is an error by default
+	if _, ok := data["exists"]; !ok {
+		stateMap["exists"] = tftypes.NewValue(tftypes.Bool, true)
+	}
+	if _, ok := data["fail_if_missing"]; !ok {
+		stateMap["fail_if_missing"] = tftypes.NewValue(tftypes.Bool, true)
+	}
+	stateValue := tftypes.NewValue(getDataSourceObjectAttributeTypes(), fillNulls(getDataSourceObjectAttributeTypes(), stateMap))
 	return datasource.ReadResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -243,8 +391,24 @@ func getDataSourceObjectAttributeTypes() tftypes.Object {
 			"name":            tftypes.String,
 			"directory":       tftypes.String,
 			"permissions":     tftypes.String,
+			"owner":           tftypes.String,
+			"group":           tftypes.String,
 			"contents":        tftypes.String,
+			"contents_base64": tftypes.String,
 			"hmac_secret_key": tftypes.String,
+			"hmac_algorithm":  tftypes.String,
+			"modified_time":   tftypes.String,
+			"access_time":     tftypes.String,
+			"xattrs":          tftypes.Map{ElementType: tftypes.String},
+			"selinux_context": tftypes.String,
+			"exists":          tftypes.Bool,
+			"fail_if_missing": tftypes.Bool,
+			"encryption": tftypes.Object{
+				AttributeTypes: map[string]tftypes.Type{
+					"key":      tftypes.String,
+					"identity": tftypes.String,
+				},
+			},
 		},
 	}
 }
@@ -255,3 +419,100 @@ func getLocalDataSourceSchema() *datasource.SchemaResponse {
 	testResource.Schema(context.Background(), datasource.SchemaRequest{}, r)
 	return r
 }
+
+func TestLocalDataSourceEncryption(t *testing.T) {
+	testKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // printf '0123456789abcdef0123456789abcdef' | base64
+	encrypted, err := encryption.Encrypt("this is an encryption test", nil, testKey)
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	testCases := []struct {
+		name      string
+		written   string
+		want      string
+		wantError string
+	}{
+		{"Encrypted", encrypted, "this is an encryption test", ""},
+		{"Not encrypted", "this is an encryption test", "", "the file isn't encrypted"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &c.MemoryFileClient{}
+			// the key comes from the provider configuration
+			fit := LocalDataSource{client: client, providerData: &provider_data.ProviderData{EncryptionKey: testKey}}
+			if err := client.Create(".", "encrypted.tmp", tc.written, "0600"); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			req := getDataSourceReadRequest(t, map[string]string{"name": "encrypted.tmp", "directory": "."})
+			// the config can't be changed, so the block is set in a state with the same schema
+			config := tfsdk.State{Raw: req.Config.Raw, Schema: req.Config.Schema}
+			config.SetAttribute(context.Background(), path.Root("encryption"), &LocalDataSourceEncryptionModel{
+				Key:      types.StringNull(),
+				Identity: types.StringNull(),
+			})
+			req.Config.Raw = config.Raw
+			resp := getDataSourceReadResponseContainer()
+			fit.Read(context.Background(), req, &resp)
+			if tc.wantError != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), tc.wantError) {
+					t.Errorf("Read() errors are %v; want an error containing %q", resp.Diagnostics, tc.wantError)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Read() errors: %v", resp.Diagnostics)
+			}
+			var got LocalDataSourceModel
+			resp.State.Get(context.Background(), &got)
+			if got.Contents.ValueString() != tc.want {
+				t.Errorf("Read() contents are %q; want %q", got.Contents.ValueString(), tc.want)
+			}
+		})
+	}
+}
+
+func TestLocalDataSourceMissing(t *testing.T) {
+	testCases := []struct {
+		name      string
+		have      map[string]string
+		want      datasource.ReadResponse
+		wantError string
+	}{
+		{
+			"Fail by default",
+			map[string]string{"name": "missing.tmp", "directory": "/tmp/missing"},
+			datasource.ReadResponse{},
+			"the file '/tmp/missing/missing.tmp' doesn't exist",
+		},
+		{
+			"Allowed",
+			map[string]string{"name": "missing.tmp", "directory": "/tmp/missing", "fail_if_missing": "false"},
+			getDataSourceReadResponse(t, map[string]string{
+				"name":            "missing.tmp",
+				"directory":       "/tmp/missing",
+				"hmac_algorithm":  "sha256",
+				"exists":          "false",
+				"fail_if_missing": "false",
+			}),
+			"",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fit := LocalDataSource{client: &c.MemoryFileClient{}}
+			resp := getDataSourceReadResponseContainer()
+			fit.Read(context.Background(), getDataSourceReadRequest(t, tc.have), &resp)
+			if tc.wantError != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), tc.wantError) {
+					t.Errorf("Read() errors are %v; want an error containing %q", resp.Diagnostics, tc.wantError)
+				}
+				return
+			}
+			// a missing file doesn't have extended attributes
+			tc.want.State.SetAttribute(context.Background(), path.Root("xattrs"), types.MapNull(types.StringType))
+			if diff := cmp.Diff(tc.want, resp); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -6,25 +6,50 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
 	c "github.com/rancher/terraform-provider-file/internal/provider/file_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/lock"
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/permissions"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
+	"golang.org/x/crypto/blake2b"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -32,9 +57,34 @@ import (
 // These will fail at compilation time if the implementation is not satisfied.
 var _ resource.Resource = &LocalResource{}
 var _ resource.ResourceWithImportState = &LocalResource{}
+var _ resource.ResourceWithMoveState = &LocalResource{}
+var _ resource.ResourceWithModifyPlan = &LocalResource{}
+var _ resource.ResourceWithValidateConfig = &LocalResource{}
 
 const unprotectedHmacSecret = "this-is-the-hmac-secret-key-that-will-be-used-to-calculate-the-hash-of-unprotected-files"
 
+const defaultHmacAlgorithm = "sha256"
+
+// originalKey is the private state key where Create records the file it replaced, see 'destroy_behavior'.
+const originalKey = "original"
+
+// originalSuffix is added to the name of the copy of the file which was at the path before the resource was created.
+const originalSuffix = ".original"
+
+// driftKey is the private state key where Read saves the attributes it found changed on disk.
+const driftKey = "drifted"
+
+// rfc3339 matches the format of 'modified_time' and 'access_time', the times are parsed when they are applied.
+var rfc3339 = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+
+// hmacAlgorithms maps the 'hmac_algorithm' values to their hash functions.
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha256":      sha256.New,
+	"sha384":      sha512.New384,
+	"sha512":      sha512.New,
+	"blake2b-256": newBlake2b256,
+}
+
 func NewLocalResource() resource.Resource {
 	return &LocalResource{
 		client: &c.OsFileClient{},
@@ -42,18 +92,79 @@ func NewLocalResource() resource.Resource {
 }
 
 type LocalResource struct {
-	client c.FileClient
+	client       c.FileClient
+	providerData *provider_data.ProviderData
 }
 
 // LocalResourceModel describes the resource data model.
 type LocalResourceModel struct {
-	ID            types.String `tfsdk:"id"`
-	Name          types.String `tfsdk:"name"`
-	Contents      types.String `tfsdk:"contents"`
-	Directory     types.String `tfsdk:"directory"`
-	Permissions   types.String `tfsdk:"permissions"`
-	HmacSecretKey types.String `tfsdk:"hmac_secret_key"`
-	Protected     types.Bool   `tfsdk:"protected"`
+	ID             types.String `tfsdk:"id"`
+	Name           types.String `tfsdk:"name"`
+	Contents       types.String `tfsdk:"contents"`
+	ContentsBase64 types.String `tfsdk:"contents_base64"`
+	// ContentsWo is always null in the plan and state, Create and Update get it from the config.
+	ContentsWo        types.String `tfsdk:"contents_wo"`
+	ContentsWoVersion types.Int64  `tfsdk:"contents_wo_version"`
+	Source            types.String `tfsdk:"source"`
+	SourceSha256      types.String `tfsdk:"source_sha256"`
+	StoreContents     types.Bool   `tfsdk:"store_contents"`
+	ContentsSha256    types.String `tfsdk:"contents_sha256"`
+	Directory         types.String `tfsdk:"directory"`
+	Permissions       types.String `tfsdk:"permissions"`
+	Owner             types.String `tfsdk:"owner"`
+	Group             types.String `tfsdk:"group"`
+	HmacSecretKey     types.String `tfsdk:"hmac_secret_key"`
+	// PreviousHmacSecretKey is only used by Update, to validate the id in state while the key is rotated.
+	PreviousHmacSecretKey types.String          `tfsdk:"previous_hmac_secret_key"`
+	HmacAlgorithm         types.String          `tfsdk:"hmac_algorithm"`
+	Protected             types.Bool            `tfsdk:"protected"`
+	DestroyBehavior       types.String          `tfsdk:"destroy_behavior"`
+	IfExists              types.String          `tfsdk:"if_exists"`
+	CreateDirectories     types.Bool            `tfsdk:"create_directories"`
+	CreateParentDirs      types.Bool            `tfsdk:"create_parent_directories"`
+	DirectoryPermissions  types.String          `tfsdk:"directory_permissions"`
+	CreatedDirectories    types.List            `tfsdk:"created_directories"`
+	ModifiedTime          types.String          `tfsdk:"modified_time"`
+	AccessTime            types.String          `tfsdk:"access_time"`
+	PreserveMtime         types.Bool            `tfsdk:"preserve_mtime_if_unchanged"`
+	Xattrs                types.Map             `tfsdk:"xattrs"`
+	SelinuxContext        types.String          `tfsdk:"selinux_context"`
+	Backup                *LocalBackupModel     `tfsdk:"backup"`
+	Backups               types.List            `tfsdk:"backups"`
+	Lock                  *LocalLockModel       `tfsdk:"lock"`
+	LineEndings           types.String          `tfsdk:"line_endings"`
+	Encoding              types.String          `tfsdk:"encoding"`
+	Encryption            *LocalEncryptionModel `tfsdk:"encryption"`
+}
+
+// LocalBackupModel describes the backup block, it is nil when the block isn't given.
+type LocalBackupModel struct {
+	Count        types.Int64  `tfsdk:"count"`
+	SuffixFormat types.String `tfsdk:"suffix_format"`
+}
+
+// LocalLockModel describes the lock block, it is nil when the block isn't given.
+type LocalLockModel struct {
+	Mode    types.String `tfsdk:"mode"`
+	Timeout types.String `tfsdk:"timeout"`
+}
+
+// LocalEncryptionModel describes the encryption block, it is nil when the block isn't given.
+type LocalEncryptionModel struct {
+	Recipients types.List   `tfsdk:"recipients"`
+	Key        types.String `tfsdk:"key"`
+	Identity   types.String `tfsdk:"identity"`
+}
+
+// originalFile is the file which was at the path before the resource was created, it is saved in private state for 'restore'.
+// The contents are copied to the backup next to the file rather than saved, private state isn't encrypted.
+type originalFile struct {
+	Exists      bool   `json:"exists"`
+	Directory   string `json:"directory"`
+	Name        string `json:"name"`
+	Backup      string `json:"backup"` // the name of the copy in the same directory, it has the owner of the original
+	Sha256      string `json:"sha256"` // the hash of the contents, the backup must still match it
+	Permissions string `json:"permissions"`
 }
 
 func (r *LocalResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -62,7 +173,14 @@ func (r *LocalResource) Metadata(_ context.Context, req resource.MetadataRequest
 
 func (r *LocalResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Local File resource",
+		MarkdownDescription: "Local File resource. " +
+			"A `local_file` or `local_sensitive_file` from the hashicorp/local provider can be moved to this resource with a `moved` block, " +
+			"the file keeps its path, contents, and permissions, it is unprotected, and its parent directories are created like `local_file` does. " +
+			"Every write goes to a temporary file in the same directory which is then renamed over the file, so readers never see a partial file. " +
+			"This means a symlink at the path is replaced by the file rather than written through, " +
+			"and other hard links to the file keep the old contents. " +
+			"The owner and extended attributes of the replaced file, including POSIX ACLs and the SELinux context, are copied to the new file " +
+			"where the user running Terraform is allowed to set them, an unprivileged user loses the attributes it can't set, eg. 'trusted.' attributes.",
 
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -70,9 +188,104 @@ func (r *LocalResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Required:            true,
 			},
 			"contents": schema.StringAttribute{
-				MarkdownDescription: "File contents, required.",
-				Required:            true,
+				MarkdownDescription: "File contents, one of 'contents', 'contents_base64', 'contents_wo', or 'source' is required.",
+				Optional:            true,
 				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.Expressions{
+						path.MatchRoot("contents_base64"),
+						path.MatchRoot("contents_wo"),
+						path.MatchRoot("source"),
+					}...),
+				},
+			},
+			"contents_base64": schema.StringAttribute{
+				MarkdownDescription: "Base64 encoded file contents, use this instead of 'contents' to write binary data. " +
+					"The decoded bytes are written to the file and used to calculate the id. " +
+					"Conflicts with 'contents' and 'source'.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"contents_wo": schema.StringAttribute{
+				MarkdownDescription: "Write-only file contents. " +
+					"This value is never saved in the plan or state, only its sha256 hash is kept in 'contents_sha256' to detect changes to the file. " +
+					"Increment 'contents_wo_version' to write a new value, " +
+					"the file is also rewritten when the hash of the configured value doesn't match the file on disk. " +
+					"Conflicts with 'contents', 'contents_base64', and 'source'.",
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+			},
+			"contents_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "A version number for 'contents_wo', Terraform can't see changes to write-only values so update this to trigger a write.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AlsoRequires(path.Expressions{
+						path.MatchRoot("contents_wo"),
+					}...),
+				},
+			},
+			"source": schema.StringAttribute{
+				MarkdownDescription: "Path to an existing file to copy to the new file, use this instead of 'contents' for large files. " +
+					"The source is streamed to the destination, its contents are never loaded into memory or saved in the state. " +
+					"Changes to the source are detected by its sha256 hash, see 'source_sha256'. " +
+					"Conflicts with 'contents' and 'contents_base64'.",
+				Optional: true,
+			},
+			"source_sha256": schema.StringAttribute{
+				MarkdownDescription: "The sha256 hash of the file contents when 'source' is used. " +
+					"This is calculated from the source at plan time, a change in the source will show up as an update.",
+				Computed: true,
+			},
+			"store_contents": schema.BoolAttribute{
+				MarkdownDescription: "Whether or not to save the contents of the file on disk in the state, defaults to true. " +
+					"When this is false, Read doesn't copy the file into the state, it compares the sha256 hash of the file to 'contents_sha256' instead. " +
+					"Any difference between the hash of the configured contents and the hash in state will replace the file, " +
+					"'contents_wo' on its own updates the file in place. " +
+					"Terraform always keeps the configured value of 'contents' or 'contents_base64' in state, " +
+					"so they can't be used when this is false, give the contents with 'contents_wo' or 'source' so the state only holds the hash.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+			"contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "The sha256 hash of the file contents when 'store_contents' is false or 'contents_wo' is used.",
+				Computed:            true,
+			},
+			"line_endings": schema.StringAttribute{
+				MarkdownDescription: "The line endings to write, one of 'lf', 'crlf', or 'preserve', defaults to 'preserve'. " +
+					"'lf' and 'crlf' replace every line ending in the contents, 'preserve' writes the contents as they are. " +
+					"The file is converted back when it is read, so the converted file matches 'contents'. " +
+					"When 'store_contents' is false or 'contents_wo' is used, 'contents_sha256' is the hash of the contents as they read back, " +
+					"the same conversion is applied to the configured contents before they are hashed, so any line endings can be given. " +
+					"Conflicts with 'contents_base64' and 'source', which are always written as they are.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("preserve"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("lf", "crlf", "preserve"),
+					stringvalidator.ConflictsWith(path.Expressions{
+						path.MatchRoot("contents_base64"),
+						path.MatchRoot("source"),
+					}...),
+				},
+			},
+			"encoding": schema.StringAttribute{
+				MarkdownDescription: "The text encoding to write, one of 'utf-8', 'utf-8-bom', 'utf-16le', or 'latin1', defaults to 'utf-8'. " +
+					"'utf-16le' files start with a byte order mark, like 'utf-8-bom'. " +
+					"'latin1' can't encode characters above U+00FF, contents with them are an error. " +
+					"The file is decoded when it is read, so the encoded file matches 'contents'. " +
+					"Conflicts with 'contents_base64' and 'source', which are always written as they are.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("utf-8"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("utf-8", "utf-8-bom", "utf-16le", "latin1"),
+					stringvalidator.ConflictsWith(path.Expressions{
+						path.MatchRoot("contents_base64"),
+						path.MatchRoot("source"),
+					}...),
+				},
 			},
 			"directory": schema.StringAttribute{
 				MarkdownDescription: "The directory where the file will be placed, defaults to the current working directory.",
@@ -81,31 +294,85 @@ func (r *LocalResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Default:             stringdefault.StaticString("."),
 			},
 			"permissions": schema.StringAttribute{
-				MarkdownDescription: "The file permissions to assign to the file, defaults to '0600'.",
-				Optional:            true,
-				Computed:            true,
-				Default:             stringdefault.StaticString("0600"),
+				MarkdownDescription: "The file permissions to assign to the file, defaults to '0600'. " +
+					"Either octal, eg. '0640' or '4755' with the setuid, setgid, and sticky bits, " +
+					"or symbolic, eg. 'u=rw,g=r,o=' or 'u=rwxs,go=rx', symbolic permissions start from none and ignore the umask. " +
+					"The file always gets exactly these permissions, the umask of the provider doesn't apply.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("0600"),
+				Validators: []validator.String{
+					permissions.Validator(),
+				},
+			},
+			"owner": schema.StringAttribute{
+				MarkdownDescription: "The user which owns the file, as a name or numeric id. " +
+					"Defaults to the provider's 'default_owner', when neither is set the file is owned by the user running Terraform. " +
+					"Giving a file to another user usually requires running Terraform as root.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "The group which owns the file, as a name or numeric id. " +
+					"Defaults to the provider's 'default_group', when neither is set the file has the primary group of the user running Terraform.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"hmac_secret_key": schema.StringAttribute{
 				MarkdownDescription: "A string used to generate the file identifier, " +
 					"you can pass this value in the environment variable `TF_FILE_HMAC_SECRET_KEY`. " +
 					"The provider will use a hard coded value as the secret key for unprotected files. " +
-					"As this is used to calculate the id of the file, it can't be updated, any change will force a recreate. " +
-					"Since this also protects delete operations, you will need to first remove the old resource from your " +
-					"configuration with the old key, then add a new resource with the new key.",
+					"As this is used to calculate the id of the file, changing it will force a recreate, " +
+					"unless the old key is given in 'previous_hmac_secret_key' to rotate the key in place.",
 				Optional:  true,
 				Computed:  true,
 				Sensitive: true,
 				// This is for arguments that may be calculated by the provider if left empty.
 				// It tells the Plan that this argument, if unspecified, can eventually be whatever is in state.
-				// Modifying this is not possible as it is used to calculate the id of the file, update forces recreate.
+				// This is used to calculate the id of the file, so changing it forces recreate unless the key is being rotated.
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIf(
+						requiresReplaceUnlessRotating,
+						"Changing the key requires replacing the file, unless previous_hmac_secret_key is set.",
+						"Changing the key requires replacing the file, unless 'previous_hmac_secret_key' is set.",
+					),
+				},
+			},
+			"previous_hmac_secret_key": schema.StringAttribute{
+				MarkdownDescription: "The key which calculated the id currently in state, set this to rotate the key of a protected file. " +
+					"Give the new key in 'hmac_secret_key' (or `TF_FILE_HMAC_SECRET_KEY`) and the new id in 'id', " +
+					"the current id is validated with this key and the state is updated in place. " +
+					"The file isn't rewritten unless its contents, path, or permissions also change. " +
+					"This can be removed from the configuration after the rotation is applied.",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.Expressions{
+						path.MatchRoot("protected"),
+					}...),
+				},
+			},
+			"hmac_algorithm": schema.StringAttribute{
+				MarkdownDescription: "The hash algorithm used for the HMAC which calculates the id, one of " +
+					"'sha256', 'sha384', 'sha512', or 'blake2b-256', defaults to 'sha256'. " +
+					"The algorithm is saved in state, changing it recalculates the id in place without rewriting the file. " +
+					"When the file is protected the new id must be given, the id in state is still validated with the previous algorithm.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(defaultHmacAlgorithm),
+				Validators: []validator.String{
+					stringvalidator.OneOf(hmacAlgorithmNames()...),
 				},
 			},
 			"id": schema.StringAttribute{
-				MarkdownDescription: "Identifier derived from sha256+HMAC hash of file contents. " +
+				MarkdownDescription: "Identifier derived from HMAC hash of file contents, see 'hmac_algorithm'. " +
 					"When setting 'protected' to true this argument is required. " +
 					"However, when 'protected' is false then this should be left empty (computed by the provider).",
 				Optional: true,
@@ -132,228 +399,797 @@ func (r *LocalResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				},
 				Default: booldefault.StaticBool(false),
 			},
+			"if_exists": schema.StringAttribute{
+				MarkdownDescription: "What to do when a file already exists at the path as the resource is created, " +
+					"one of 'overwrite', 'fail', or 'adopt', defaults to 'overwrite'. " +
+					"'overwrite' replaces the file without notice. " +
+					"'fail' stops with an error describing the existing file, both while planning and when the file is created. " +
+					"'adopt' takes over the existing file, 'permissions', 'owner', and 'group' are planned from the existing file " +
+					"when they aren't configured, ahead of the provider defaults, so they are kept. " +
+					"The contents are always configured, the plan warns which attributes of the existing file will change " +
+					"and the file is then updated to match the configuration. " +
+					"This only applies to creating the resource, use an import block to see the full difference before adopting a file.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("overwrite"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("overwrite", "fail", "adopt"),
+				},
+			},
+			"create_directories": schema.BoolAttribute{
+				MarkdownDescription: "The earlier name of 'create_parent_directories', it sets 'create_parent_directories' and does nothing else. " +
+					"Conflicts with 'create_parent_directories'.",
+				Optional:           true,
+				DeprecationMessage: "Use 'create_parent_directories', 'create_directories' was merged into it.",
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(path.Expressions{
+						path.MatchRoot("create_parent_directories"),
+					}...),
+				},
+			},
+			"create_parent_directories": schema.BoolAttribute{
+				MarkdownDescription: "Whether to create the missing parents of 'directory' when the file is created or moved, defaults to false. " +
+					"The directories which are created are saved in 'created_directories', " +
+					"they are removed when the file is destroyed or moved away, but only if they are empty.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"directory_permissions": schema.StringAttribute{
+				MarkdownDescription: "The permissions of directories created by 'create_parent_directories', " +
+					"defaults to the provider's 'default_directory_permissions', or '0700' when neither is set. " +
+					"Existing directories are never changed.",
+				Optional: true,
+				Validators: []validator.String{
+					permissions.Validator(),
+				},
+			},
+			"created_directories": schema.ListAttribute{
+				MarkdownDescription: "The directories created for the file by 'create_parent_directories', from the top down. " +
+					"eg. if 'directory' = '/path/to/new/directory' and '/path/to' already exists, " +
+					"then this is ['/path/to/new', '/path/to/new/directory'].",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"modified_time": schema.StringAttribute{
+				MarkdownDescription: "The modification time of the file in RFC 3339 format, eg. '2025-01-02T15:04:05Z'. " +
+					"When this is set the time is applied after every write and any other time found on disk is reported as a change, " +
+					"times are compared to the second. When it isn't set the file gets the time it was written.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(rfc3339, "must be an RFC 3339 time, eg. '2025-01-02T15:04:05Z'"),
+				},
+			},
+			"access_time": schema.StringAttribute{
+				MarkdownDescription: "The access time of the file in RFC 3339 format, eg. '2025-01-02T15:04:05Z'. " +
+					"When this is set the time is applied after every write, and put back after the provider reads the file, " +
+					"other reads change it depending on how the filesystem is mounted, eg. 'relatime' or 'noatime'.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(rfc3339, "must be an RFC 3339 time, eg. '2025-01-02T15:04:05Z'"),
+				},
+			},
+			"xattrs": schema.MapAttribute{
+				MarkdownDescription: "Extended attributes to give the file, the keys are the names with their namespace, eg. 'user.owner'. " +
+					"They are set after every write, attributes which are removed from this map are removed from the file, " +
+					"other attributes on the file are left alone, they are copied to the new file on every write as far as the user running Terraform is allowed to set them. " +
+					"Use 'selinux_context' for the SELinux context. " +
+					"The filesystem must support extended attributes, setting them on a filesystem which doesn't is an error.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(
+						stringvalidator.LengthAtLeast(1),
+						stringvalidator.NoneOf(xattr.SELinux),
+					),
+				},
+			},
+			"selinux_context": schema.StringAttribute{
+				MarkdownDescription: "The SELinux context to give the file, eg. 'system_u:object_r:httpd_sys_content_t:s0'. " +
+					"This is set after every write, otherwise the file gets the default context of its directory. " +
+					"When this isn't set the context isn't managed.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"preserve_mtime_if_unchanged": schema.BoolAttribute{
+				MarkdownDescription: "Whether to skip rewriting a file which already has the planned contents, defaults to false. " +
+					"Only the path and permissions of the file are changed, so it keeps its modification time. " +
+					"This also applies to an existing file at the path when the resource is created, unless 'if_exists' is 'fail'.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"destroy_behavior": schema.StringAttribute{
+				MarkdownDescription: "What to do with the file when the resource is destroyed, one of 'delete', 'retain', or 'restore', defaults to 'delete'. " +
+					"'retain' leaves the file on disk. " +
+					"'restore' puts back the contents, permissions, and ownership of the file which was at the path before the resource was created, " +
+					"or deletes the file if there wasn't one. " +
+					"When the resource is created with 'restore' the original file is copied to '<name>.original' next to it, " +
+					"with the same permissions and ownership, the resource's private state only records its path, sha256 hash, and permissions, " +
+					"so the original contents are never saved in the state. " +
+					"On destroy the copy is moved back over the file, if it was removed or changed the destroy fails and the file is left alone. " +
+					"The copy is taken while the file is locked, when creating the resource fails the copy is moved back over the file, " +
+					"or the new file is deleted if there wasn't an original. " +
+					"An existing '<name>.original' file which doesn't match the file stops the resource from being created, one which matches is reused. " +
+					"Only a privileged user can give the copy the owner of the original, otherwise the restored file is owned by the user running Terraform. " +
+					"This must be set when the resource is created, changing it to 'restore' later deletes the file on destroy.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("delete"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("delete", "retain", "restore"),
+				},
+			},
+			"backups": schema.ListAttribute{
+				MarkdownDescription: "The paths of the backups of the file, newest first. " +
+					"This is empty until the file is first changed, and null without a 'backup' block.",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"backup": schema.SingleNestedBlock{
+				MarkdownDescription: "Keep backups of the previous versions of the file. " +
+					"Before the file is changed by an update, or deleted or restored by a destroy, " +
+					"it is copied next to itself with the permissions of the original, then the oldest backups over 'count' are removed. " +
+					"Only the backups listed in 'backups' are renamed or removed, other files with similar names are left alone, " +
+					"when a numbered backup would replace one of them, eg. 'app.log.1' written by a log rotation, the change fails instead. " +
+					"When the file moves the backup of its last version is made at the old path, the backups stay listed in 'backups', " +
+					"numbered backups are renamed next to the file by the next backup and every backup is still removed once it is over 'count'. " +
+					"Backups are left on disk when the resource is destroyed.",
+				Attributes: map[string]schema.Attribute{
+					"count": schema.Int64Attribute{
+						MarkdownDescription: "The number of backups to keep.",
+						Required:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"suffix_format": schema.StringAttribute{
+						MarkdownDescription: "How backups are named, 'numbered' adds '.1' to the newest backup and shifts the older backups up, " +
+							"'timestamp' adds the UTC time of the backup, eg. '.20250102T150405.000000000Z'. Defaults to 'numbered'.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf("numbered", "timestamp"),
+						},
+					},
+				},
+			},
+			"lock": schema.SingleNestedBlock{
+				MarkdownDescription: "Take an advisory lock while the file is read, written, or deleted, " +
+					"so the provider doesn't interleave its writes with other processes which lock the file. " +
+					"The lock is only advisory, processes which don't take it aren't stopped.",
+				Attributes: map[string]schema.Attribute{
+					"mode": schema.StringAttribute{
+						MarkdownDescription: "How the file is locked, both modes use '<name>.lock' next to the file. " +
+							"'flock' locks the lock file with flock(2), other processes have to flock '<name>.lock' rather than the file, " +
+							"because every write replaces the file and a lock on the file would stay on the replaced one. " +
+							"The lock file is left in place while the file exists and is removed when the file is destroyed, this isn't available on Windows. " +
+							"'lockfile' creates '<name>.lock', waiting while it exists, " +
+							"the lock file records the process holding it and is removed when the lock is released. " +
+							"Remove a lock file left by 'flock' before switching to 'lockfile'. Defaults to 'flock'.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf(lock.Flock, lock.Lockfile),
+						},
+					},
+					"timeout": schema.StringAttribute{
+						MarkdownDescription: "How long to wait for another process to release the lock, eg. '30s' or '2m', defaults to '30s'. " +
+							"When it expires the error says which process holds the lock, when that can be found.",
+						Optional: true,
+						Validators: []validator.String{
+							lock.TimeoutValidator(),
+						},
+					},
+				},
+			},
+			"encryption": schema.SingleNestedBlock{
+				MarkdownDescription: "Encrypt the file, so the contents are only readable with the key or an age identity. " +
+					"The contents are encrypted in memory, only the ciphertext is written to disk, including the temporary file used for the write. " +
+					"The file is decrypted when it is read and compared with the configured contents, " +
+					"a file which is found unencrypted is removed from the state, so the plan replaces it with an encrypted file. " +
+					"Changing the recipients or the key rewrites the file. Conflicts with 'source'.",
+				Validators: []validator.Object{
+					objectvalidator.ConflictsWith(path.MatchRoot("source")),
+				},
+				Attributes: map[string]schema.Attribute{
+					"recipients": schema.ListAttribute{
+						MarkdownDescription: "The age recipients to encrypt the file for, eg. 'age1...'. " +
+							"Reading the file needs the identity of one of them, " +
+							"from 'identity', the provider's 'age_identity', or the `TF_FILE_AGE_IDENTITY` environment variable. " +
+							"When this isn't given the file is encrypted with AES-256-GCM using the key.",
+						ElementType: types.StringType,
+						Optional:    true,
+						Validators: []validator.List{
+							listvalidator.SizeAtLeast(1),
+							listvalidator.ValueStringsAre(encryption.RecipientValidator()),
+							listvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("key")),
+						},
+					},
+					"key": schema.StringAttribute{
+						MarkdownDescription: "The base64 encoded AES-256 key, eg. from 'openssl rand -base64 32'. " +
+							"Defaults to the provider's 'encryption_key', then the `TF_FILE_ENCRYPTION_KEY` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+						Validators: []validator.String{
+							encryption.KeyValidator(),
+						},
+					},
+					"identity": schema.StringAttribute{
+						MarkdownDescription: "The age identity which decrypts the file, eg. 'AGE-SECRET-KEY-1...', the contents of an identity file are accepted. " +
+							"Defaults to the provider's 'age_identity', then the `TF_FILE_AGE_IDENTITY` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+			},
 		},
 	}
 }
 
 // Configure the provider for the resource if necessary.
-func (r *LocalResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+func (r *LocalResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
+	data, ok := req.ProviderData.(*provider_data.ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider_data.ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	r.providerData = data
+	if client, ok := r.client.(*c.OsFileClient); ok {
+		client.Sandbox = data.Sandbox
+		client.SyncDirectory = data.SyncDirectory
+	}
 }
 
-// We should:
-// - generate reality and state in the Create function
-// - update state to match reality in the Read function
-// - update state to config and update reality to config in the Update function by looking for differences in the state and the config (trust read to collect reality)
-// - destroy reality and state in the Destroy function
+// ValidateConfig rejects 'contents' and 'contents_base64' when 'store_contents' is false,
+// Terraform saves every configured value in state so the contents have to come from 'contents_wo' or 'source'.
+func (r *LocalResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var storeContents types.Bool
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("store_contents"), &storeContents)...)
+	if resp.Diagnostics.HasError() || storeContents.IsNull() || storeContents.IsUnknown() || storeContents.ValueBool() {
+		return
+	}
+	for _, attribute := range []string{"contents", "contents_base64"} {
+		var value types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(attribute), &value)...)
+		if !value.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root(attribute), "Error validating file: ",
+				fmt.Sprintf("'%s' is always saved in the state, use 'contents_wo' or 'source' when 'store_contents' is false", attribute))
+		}
+	}
+}
 
-func (r *LocalResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
-	var err error
+// ModifyPlan applies the provider level defaults to arguments which aren't set in the resource configuration.
+// It also hashes the source file, so that a change in the source shows up as an update.
+func (r *LocalResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The plan is null when the resource is being destroyed, there is nothing to modify.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
 
-	var plan LocalResourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	var config LocalResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	id := plan.ID.ValueString()
-	name := plan.Name.ValueString()
-	directory := plan.Directory.ValueString()
-	contents := plan.Contents.ValueString()
-	permString := plan.Permissions.ValueString()
-	hmacSecretKey := plan.HmacSecretKey.ValueString()
-	protected := plan.Protected.ValueBool()
 
-	key := hmacSecretKey
-	if key == "" {
-		key = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
-		if key != "" {
-			// key was in the environment, so we want to keep the secret key empty
-			plan.HmacSecretKey = types.StringValue("")
+	if !config.CreateDirectories.IsNull() {
+		// the deprecated name, the validators make sure 'create_parent_directories' isn't also set
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("create_parent_directories"), config.CreateDirectories)...)
+	}
+
+	if r.providerData != nil {
+		if config.Directory.IsNull() && r.providerData.RootDirectory != "" {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("directory"), r.providerData.RootDirectory)...)
+		}
+		if config.Permissions.IsNull() && r.providerData.DefaultFilePermissions != "" {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("permissions"), r.providerData.DefaultFilePermissions)...)
+		}
+		if config.Owner.IsNull() && r.providerData.DefaultOwner != "" {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("owner"), r.providerData.DefaultOwner)...)
+		}
+		if config.Group.IsNull() && r.providerData.DefaultGroup != "" {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("group"), r.providerData.DefaultGroup)...)
 		}
 	}
-	if protected {
-		err := validateProtected(protected, id, key, contents)
-		if err != nil {
-			resp.Diagnostics.AddError("Error creating file: ", err.Error())
-			return
-		} // at this point we have an id, key, contents, protected is true, and our calculated id matches what was provided
-	} else {
-		id, err = calculateID(contents, unprotectedHmacSecret)
+
+	r.planContentsSha256(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case config.Source.IsNull():
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("source_sha256"), types.StringNull())...)
+	case !config.Source.IsUnknown():
+		source := config.Source.ValueString()
+		hash, err := r.client.Hash(filepath.Dir(source), filepath.Base(source))
 		if err != nil {
-			resp.Diagnostics.AddError("Error creating file: ", "Problem calculating id from hard coded key: "+err.Error())
+			resp.Diagnostics.AddAttributeError(path.Root("source"), "Error reading source: ", err.Error())
 			return
 		}
-		plan.ID = types.StringValue(id)
-		// the file isn't protected so we want the key to be an empty string in state
-		plan.HmacSecretKey = types.StringValue("")
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("source_sha256"), hash)...)
 	}
 
-	tflog.Debug(ctx, fmt.Sprintf("Client: #%v", r.client))
+	r.planID(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.warnDrift(ctx, req, resp)
+	r.checkExisting(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.planComputedLists(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if err = r.client.Create(directory, name, contents, permString); err != nil {
-		resp.Diagnostics.AddError("Error creating file: ", err.Error())
+	if r.providerData == nil {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
-	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+	// Reject paths outside of the sandbox at plan time, before anything is touched.
+	var plan LocalResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.Directory.IsUnknown() || plan.Name.IsUnknown() {
+		return
+	}
+	if err := r.providerData.Sandbox.Check(filepath.Join(plan.Directory.ValueString(), plan.Name.ValueString())); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Path not allowed: ", err.Error())
+	}
 }
 
-// Read runs at refresh time, which happens before all other functions and every time a function would be called.
-// Read also runs when no other functions would be called.
-// After Read, if the contents of the state don't match the contents of the plan, then the resource will be reconciled.
-// We want to update the state to match reality so that differences can be detected.
-func (r *LocalResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
-
-	var state LocalResourceModel
-	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+// planID calculates the id while planning, so unprotected files don't show it as known after apply
+// and protected files with the wrong id or key fail before anything is changed.
+// This follows Create and Update, the id is left unknown when the contents or key aren't known yet.
+func (r *LocalResource) planID(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan LocalResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	sName := state.Name.ValueString()
-	sDirectory := state.Directory.ValueString()
-	sContents := state.Contents.ValueString()
-	sPerm := state.Permissions.ValueString()
-	sHmacSecretKey := state.HmacSecretKey.ValueString()
-
-	// If Possible, we should avoid reading the file into memory
-
-	// The "real" (non-calculated) parts of the file are the path, the contents, and the mode
-
-	// If the file doesn't exist at the path, then we need to (re)create it
-	perm, contents, err := r.client.Read(sDirectory, sName)
-	if err != nil && err.Error() == "file not found" {
-		resp.State.RemoveResource(ctx)
+	var configID types.String
+	var configKey types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("id"), &configID)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("hmac_secret_key"), &configKey)...)
+	if writeOnly(plan) {
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &plan.ContentsWo)...)
+	}
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err != nil {
-		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+	if plan.Protected.IsUnknown() || configID.IsUnknown() || configKey.IsUnknown() || plan.HmacAlgorithm.IsUnknown() ||
+		plan.Contents.IsUnknown() || plan.ContentsBase64.IsUnknown() || plan.ContentsWo.IsUnknown() || plan.Source.IsUnknown() {
 		return
 	}
 
-	if contents != sContents {
-		// update state with actual contents
-		state.Contents = types.StringValue(contents)
-		// if we are updating the state contents, should we also update the state id?
-		// state should reflect reality, but we want to make sure that protected files don't change without the correct id
-		// we can't error here because then the user won't have the chance to update to the proper id?
-		if sHmacSecretKey == "" {
-			sHmacSecretKey = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
-		}
-
-		id, err := calculateID(contents, sHmacSecretKey)
-		if err != nil {
-			resp.Diagnostics.AddError("Error reading file: ", "Problem calculating id from key: "+err.Error())
-			return
+	if plan.Protected.ValueBool() {
+		key := r.secretKey(configKey.ValueString())
+		if err := r.validateProtectedContents(plan, false, configID.ValueString(), key); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("id"), "Error planning file: ", err.Error())
 		}
-		state.ID = types.StringValue(id)
+		return
 	}
-
-	if perm != sPerm {
-		// update the state with the actual mode
-		state.Permissions = types.StringValue(perm)
+	// the contents aren't read for unprotected files, this only checks that an id or key wasn't given
+	if err := validateProtected(false, configID.ValueString(), configKey.ValueString(), "", nil); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("protected"), "Error planning file: ", err.Error())
+		return
 	}
-
-	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
-	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+	id, err := r.contentsID(plan, false, unprotectedHmacSecret)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("id"), "Error planning file: ", "Problem calculating id from hard coded key: "+err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), id)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("hmac_secret_key"), "")...)
 }
 
-// For now, we are assuming Terraform has complete control over the file
-// This means we don't need know anything about the actual file for updates, we just change the file if the plan doesn't match the state.
-// The plan has the authority here, state and reality needs to match the plan.
-func (r *LocalResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
-
-	var config LocalResourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &config)...)
+// planComputedLists plans 'backups' and 'created_directories' for an update, UseStateForUnknown keeps them from the state.
+// That is only right while Update leaves them alone, so they are unknown when Update may back up or move the file,
+// and null when the block or argument which fills them is removed.
+func (r *LocalResource) planComputedLists(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || resp.Plan.Raw.Equal(req.State.Raw) {
+		// Create fills them in, and without a change there is no Update
+		return
+	}
+	var plan, state LocalResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	if plan.Backup == nil {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("backups"), types.ListNull(types.StringType))...)
+	} else {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("backups"), types.ListUnknown(types.StringType))...)
+	}
+	switch {
+	case plan.CreateParentDirs.IsUnknown() || plan.Directory.IsUnknown():
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("created_directories"), types.ListUnknown(types.StringType))...)
+	case !plan.CreateParentDirs.ValueBool():
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("created_directories"), types.ListNull(types.StringType))...)
+	case !state.CreateParentDirs.ValueBool() || state.CreatedDirectories.IsNull() || filepath.Clean(plan.Directory.ValueString()) != filepath.Clean(state.Directory.ValueString()):
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("created_directories"), types.ListUnknown(types.StringType))...)
+	}
+}
 
-	cID := config.ID.ValueString()
-	cName := config.Name.ValueString()
-	cContents := config.Contents.ValueString()
-	cDirectory := config.Directory.ValueString()
-	cPerm := config.Permissions.ValueString()
-	cHmacSecretKey := config.HmacSecretKey.ValueString()
-	cProtected := config.Protected.ValueBool()
-
-	cKey := cHmacSecretKey
-	if cKey == "" {
-		cKey = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
+// warnDrift tells the user which attributes Read found changed on disk, these changes will be overwritten by the plan.
+func (r *LocalResource) warnDrift(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
 	}
-	if cProtected {
-		// this only validates that the key given was correctly used to generate the id, it doesn't actually protect the file
-		err := validateProtected(cProtected, cID, cKey, cContents)
-		if err != nil {
-			resp.Diagnostics.AddError("Error updating file: ", err.Error())
-			return
+	value, diags := req.Private.GetKey(ctx, driftKey)
+	resp.Diagnostics.Append(diags...)
+	if len(value) == 0 {
+		return
+	}
+	var drifted []string
+	if err := json.Unmarshal(value, &drifted); err != nil {
+		resp.Diagnostics.AddError("Error reading drift: ", err.Error())
+		return
+	}
+	var state LocalResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.AddWarning(
+		"File changed outside of Terraform",
+		fmt.Sprintf("The file '%s' was changed outside of Terraform, these attributes no longer match the last apply: %s. "+
+			"Unless the configuration was updated to match, applying this plan overwrites the changes.",
+			filepath.Join(state.Directory.ValueString(), state.Name.ValueString()), strings.Join(drifted, ", ")),
+	)
+}
+
+// checkExisting looks for a file at the path of a resource which is being created, see 'if_exists'.
+func (r *LocalResource) checkExisting(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if !req.State.Raw.IsNull() {
+		return
+	}
+	var plan LocalResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.Directory.IsUnknown() || plan.Name.IsUnknown() {
+		return
+	}
+	directory := plan.Directory.ValueString()
+	name := plan.Name.ValueString()
+
+	switch plan.IfExists.ValueString() {
+	case "fail":
+		if err := r.existingFileError(directory, name); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("if_exists"), "Error planning file: ", err.Error())
 		}
-	} else {
-		id, err := calculateID(cContents, unprotectedHmacSecret)
+	case "adopt":
+		info, err := r.client.Info(directory, name)
 		if err != nil {
-			resp.Diagnostics.AddError("Error updating file: ", "Problem calculating id from hard coded key: "+err.Error())
+			// there isn't anything to adopt, the file is created
 			return
 		}
-		config.ID = types.StringValue(id)
-		config.HmacSecretKey = types.StringValue("")
+		r.adoptAttributes(ctx, req, resp, &plan, info)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		changes := []string{}
+		if plan.Permissions.IsUnknown() || !permissions.Equal(info["Mode"], plan.Permissions.ValueString()) {
+			changes = append(changes, "permissions")
+		}
+		if !plan.Owner.IsNull() && (plan.Owner.IsUnknown() || !ownership.Matches(plan.Owner.ValueString(), info["Owner"], info["Uid"])) {
+			changes = append(changes, "owner")
+		}
+		if !plan.Group.IsNull() && (plan.Group.IsUnknown() || !ownership.Matches(plan.Group.ValueString(), info["Group"], info["Gid"])) {
+			changes = append(changes, "group")
+		}
+		if writeOnly(plan) {
+			resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &plan.ContentsWo)...)
+		}
+		if r.contentsChanged(plan, directory, name) {
+			changes = append(changes, "contents")
+		}
+		detail := "it already matches the configuration"
+		if len(changes) > 0 {
+			detail = "these attributes will change: " + strings.Join(changes, ", ")
+		}
+		resp.Diagnostics.AddAttributeWarning(path.Root("if_exists"), "Adopting existing file",
+			fmt.Sprintf("The file '%s' (%s bytes, mode %s) already exists and will be adopted, %s.",
+				filepath.Join(directory, name), info["Size"], info["Mode"], detail),
+		)
 	}
+}
 
-	// Read updates state with reality, so state = reality
-	var reality LocalResourceModel
-	resp.Diagnostics.Append(req.State.Get(ctx, &reality)...)
+// adoptAttributes plans the permissions, owner, and group of an adopted file from the existing file when they aren't configured,
+// so adopting a file only changes what the configuration asks for.
+// The contents are always configured, they are compared with the existing file rather than adopted.
+func (r *LocalResource) adoptAttributes(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, plan *LocalResourceModel, info map[string]string) {
+	var config LocalResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	if config.Permissions.IsNull() && info["Mode"] != "" {
+		plan.Permissions = types.StringValue(info["Mode"])
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("permissions"), plan.Permissions)...)
+	}
+	if owner := ownership.Value(info["Owner"], info["Uid"]); config.Owner.IsNull() && !owner.IsNull() {
+		plan.Owner = owner
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("owner"), owner)...)
+	}
+	if group := ownership.Value(info["Group"], info["Gid"]); config.Group.IsNull() && !group.IsNull() {
+		plan.Group = group
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("group"), group)...)
+	}
+}
 
-	rID := reality.ID.ValueString()
-	rName := reality.Name.ValueString()
-	rContents := reality.Contents.ValueString()
-	rDirectory := reality.Directory.ValueString()
-	rHmacSecretKey := reality.HmacSecretKey.ValueString()
-	rProtected := reality.Protected.ValueBool()
+// existingFileError describes the file at the path when there is one.
+func (r *LocalResource) existingFileError(directory string, name string) error {
+	info, err := r.client.Info(directory, name)
+	if err != nil && err.Error() == "file not found" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf(
+		"the file '%s' already exists (%s bytes, mode %s) and 'if_exists' is 'fail', "+
+			"check the name and directory, or set 'if_exists' to 'overwrite' or 'adopt' to manage the existing file",
+		filepath.Join(directory, name), info["Size"], info["Mode"],
+	)
+}
 
-	rKey := rHmacSecretKey
-	if rKey == "" {
-		rKey = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
+// contentsChanged reports whether the planned contents differ from the file at the path, unknown contents are a change.
+func (r *LocalResource) contentsChanged(plan LocalResourceModel, directory string, name string) bool {
+	if plan.Contents.IsUnknown() || plan.ContentsBase64.IsUnknown() || plan.ContentsWo.IsUnknown() || plan.Source.IsUnknown() {
+		return true
 	}
-	if rProtected {
-		// if the key was previously coded into the config then this only verifies that it was used to calculate the id properly
-		// if the key is being given in the environment variable, this validates that the given key can calculate the previous id
-		err := validateProtected(rProtected, rID, rKey, rContents) // how do I rotate keys? you can't, just remake the file, an id should be variable
+	var want string
+	if plan.Source.IsNull() {
+		// the contents are converted by 'line_endings' and 'encoding', the hash of the file is the hash of the text read back
+		contents, err := rawContents(plan)
 		if err != nil {
-			resp.Diagnostics.AddError("Error updating file: ", err.Error())
-			return
+			return true
+		}
+		if want, err = textDigest(plan, contents); err != nil {
+			return true
 		}
 	} else {
-		_, err := calculateID(rContents, unprotectedHmacSecret)
+		reader, err := r.openContents(plan, false)
 		if err != nil {
-			resp.Diagnostics.AddError("Error updating file: ", "Problem calculating id from hard coded key: "+err.Error())
+			return true
+		}
+		defer reader.Close()
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, reader); err != nil {
+			return true
+		}
+		want = hex.EncodeToString(hasher.Sum(nil))
+	}
+	existing, err := r.contentsClient(plan).Hash(directory, name)
+	return err != nil || existing != want
+}
+
+// planContentsSha256 hashes the configured contents for files which don't store their contents in state.
+// The hash is the only record of the contents, so any difference from the hash in state replaces the file.
+func (r *LocalResource) planContentsSha256(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan LocalResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	isWriteOnly := writeOnly(plan)
+	if !isWriteOnly && (!hashOnly(plan) || !plan.Source.IsNull()) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("contents_sha256"), types.StringNull())...)
+		return
+	}
+	if isWriteOnly {
+		// write-only values are only available in the config
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &plan.ContentsWo)...)
+		if resp.Diagnostics.HasError() {
 			return
 		}
 	}
+	if plan.Contents.IsUnknown() || plan.ContentsBase64.IsUnknown() || plan.ContentsWo.IsUnknown() ||
+		plan.LineEndings.IsUnknown() || plan.Encoding.IsUnknown() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("contents_sha256"), types.StringUnknown())...)
+		return
+	}
+	contents, err := rawContents(plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Error planning file: ", err.Error())
+		return
+	}
+	hash, err := textDigest(plan, contents)
+	if err != nil {
+		resp.Diagnostics.AddError("Error planning file: ", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("contents_sha256"), hash)...)
+
+	// write-only contents are updated in place, unless 'store_contents' is false
+	if (isWriteOnly && !hashOnly(plan)) || req.State.Raw.IsNull() {
+		return
+	}
+	var state LocalResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !state.ContentsSha256.IsNull() && state.ContentsSha256.ValueString() != hash {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("contents_sha256"))
+	}
+}
+
+// We should:
+// - generate reality and state in the Create function
+// - update state to match reality in the Read function
+// - update state to config and update reality to config in the Update function by looking for differences in the state and the config (trust read to collect reality)
+// - destroy reality and state in the Destroy function
+
+func (r *LocalResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
+	var err error
+
+	var plan LocalResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	id := plan.ID.ValueString()
+	name := plan.Name.ValueString()
+	directory := plan.Directory.ValueString()
+	permString := plan.Permissions.ValueString()
+	hmacSecretKey := plan.HmacSecretKey.ValueString()
+	protected := plan.Protected.ValueBool()
+	source := plan.Source.ValueString()
 
-	err := r.client.Update(rDirectory, rName, cDirectory, cName, cContents, cPerm)
+	if writeOnly(plan) {
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &plan.ContentsWo)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	contents, err := rawContents(plan)
 	if err != nil {
-		resp.Diagnostics.AddError("Error updating file: ", err.Error())
+		resp.Diagnostics.AddError("Error creating file: ", err.Error())
 		return
 	}
 
-	// the path, mode, and contents are all of the "real" parts of the file
-	// the id is calculated from the secret key and contents,
-	//   so if the config's id is correct, then its key is correct
-	//   and there isn't anything to change in reality
+	key := r.secretKey(hmacSecretKey)
+	if hmacSecretKey == "" && key != "" {
+		// key was in the provider configuration or the environment, so we want to keep the secret key empty
+		plan.HmacSecretKey = types.StringValue("")
+	}
+	if protected {
+		err := r.validateProtectedContents(plan, false, id, key)
+		if err != nil {
+			resp.Diagnostics.AddError("Error creating file: ", err.Error())
+			return
+		} // at this point we have an id, key, contents, protected is true, and our calculated id matches what was provided
+	} else {
+		id, err = r.contentsID(plan, false, unprotectedHmacSecret)
+		if err != nil {
+			resp.Diagnostics.AddError("Error creating file: ", "Problem calculating id from hard coded key: "+err.Error())
+			return
+		}
+		plan.ID = types.StringValue(id)
+		// the file isn't protected so we want the key to be an empty string in state
+		plan.HmacSecretKey = types.StringValue("")
+	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+	tflog.Debug(ctx, fmt.Sprintf("Client: #%v", r.client))
+
+	created, err := r.makeDirectory(plan, directory)
+	if err != nil {
+		resp.Diagnostics.AddError("Error creating directory: ", err.Error())
+		return
+	}
+	plan.CreatedDirectories = types.ListNull(types.StringType)
+	if plan.CreateParentDirs.ValueBool() {
+		if plan.CreatedDirectories, err = stringList(ctx, created); err != nil {
+			resp.Diagnostics.AddError("Error creating directory: ", err.Error())
+			return
+		}
+	}
+	unlock, err := r.acquireLock(plan, directory, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Error locking file: ", err.Error())
+		return
+	}
+	defer func() { resp.Diagnostics.Append(unlock()...) }()
+	if plan.IfExists.ValueString() == "fail" {
+		// the file may have been created since the plan
+		if err := r.existingFileError(directory, name); err != nil {
+			resp.Diagnostics.AddError("Error creating file: ", err.Error())
+			return
+		}
+	}
+	if plan.DestroyBehavior.ValueString() == "restore" {
+		original := r.captureOriginal(ctx, directory, name, resp)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		// the resource isn't saved when Create fails, put the original back rather than leave the copy behind
+		defer func() {
+			if !resp.Diagnostics.HasError() {
+				return
+			}
+			if err := r.putBackOriginal(original, directory, name); err != nil {
+				resp.Diagnostics.AddError("Error restoring original file: ", err.Error())
+			}
+		}()
+	}
+	switch {
+	case plan.PreserveMtime.ValueBool() && plan.IfExists.ValueString() != "fail" && r.sameContents(plan, directory, name, contents):
+		// the existing file already has the contents
+		err = r.client.Move(directory, name, directory, name, permString)
+	case plan.Source.IsNull():
+		err = r.contentsClient(plan).Create(directory, name, contents, permString)
+	default:
+		err = r.client.CreateFrom(source, directory, name, permString)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error creating file: ", err.Error())
+		return
+	}
+	if !plan.Source.IsNull() && plan.SourceSha256.IsUnknown() {
+		// the source wasn't known at plan time
+		hash, err := r.client.Hash(directory, name)
+		if err != nil {
+			resp.Diagnostics.AddError("Error creating file: ", err.Error())
+			return
+		}
+		plan.SourceSha256 = types.StringValue(hash)
+	}
+	if writeOnly(plan) || (hashOnly(plan) && plan.Source.IsNull()) {
+		hash, err := textDigest(plan, contents)
+		if err != nil {
+			resp.Diagnostics.AddError("Error creating file: ", err.Error())
+			return
+		}
+		plan.ContentsSha256 = types.StringValue(hash)
+	}
+	plan.ContentsWo = types.StringNull()
+	plan.Backups = types.ListNull(types.StringType)
+	if plan.Backup != nil {
+		// nothing has been backed up yet
+		plan.Backups = types.ListValueMust(types.StringType, []attr.Value{})
+	}
+	if err = r.applyOwnership(&plan); err != nil {
+		resp.Diagnostics.AddError("Error creating file: ", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(r.applyXattrs(ctx, plan, types.MapNull(types.StringType))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err = r.applyTimes(plan); err != nil {
+		resp.Diagnostics.AddError("Error setting file times: ", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
 }
 
-func (r *LocalResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+// Read runs at refresh time, which happens before all other functions and every time a function would be called.
+// Read also runs when no other functions would be called.
+// After Read, if the contents of the state don't match the contents of the plan, then the resource will be reconciled.
+// We want to update the state to match reality so that differences can be detected.
+func (r *LocalResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
 
 	var state LocalResourceModel
@@ -361,56 +1197,1285 @@ func (r *LocalResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	if state.HmacAlgorithm.IsNull() {
+		// files created before 'hmac_algorithm' existed used the default
+		state.HmacAlgorithm = types.StringValue(defaultHmacAlgorithm)
+	}
+	before := state
+	sName := state.Name.ValueString()
+	sDirectory := state.Directory.ValueString()
+	sHmacSecretKey := state.HmacSecretKey.ValueString()
 
-	name := state.Name.ValueString()
-	directory := state.Directory.ValueString()
+	unlock, err := r.acquireLock(state, sDirectory, sName)
+	if err != nil {
+		resp.Diagnostics.AddError("Error locking file: ", err.Error())
+		return
+	}
+	defer func() { resp.Diagnostics.Append(unlock()...) }()
 
-	protected := state.Protected.ValueBool()
-	id := state.ID.ValueString()
-	key := state.HmacSecretKey.ValueString()
-	if key == "" {
-		key = os.Getenv("TF_FILE_HMAC_SECRET_KEY")
+	// If Possible, we should avoid reading the file into memory
+	if !state.Source.IsNull() {
+		r.readDigest(ctx, &state, &state.SourceSha256, resp)
+		return
+	}
+	if hashOnly(state) || writeOnly(state) {
+		r.readDigest(ctx, &state, &state.ContentsSha256, resp)
+		return
 	}
-	contents := state.Contents.ValueString()
 
-	// we need to validate the id before we can delete a protected file
-	if protected {
-		err := validateProtected(protected, id, key, contents)
+	// The "real" (non-calculated) parts of the file are the path, the contents, and the mode
+
+	// If the file doesn't exist at the path, then we need to (re)create it
+	info, err := r.client.Info(sDirectory, sName)
+	if err != nil && err.Error() == "file not found" {
+		warnRemoved(state, resp)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+		return
+	}
+	perm, contents, err := r.contentsClient(state).Read(sDirectory, sName)
+	if errors.Is(err, encryption.ErrNotEncrypted) {
+		warnNotEncrypted(state, resp)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+		return
+	}
+	r.keepAccessTime(state, info)
+
+	sContents, err := rawContents(state)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+		return
+	}
+
+	if contents != sContents && !r.sameText(state, contents, sContents) {
+		// update state with actual contents, the comparison is done on the raw bytes
+		if state.ContentsBase64.IsNull() {
+			state.Contents = types.StringValue(contents)
+		} else {
+			state.ContentsBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(contents)))
+		}
+		// if we are updating the state contents, should we also update the state id?
+		// state should reflect reality, but we want to make sure that protected files don't change without the correct id
+		// we can't error here because then the user won't have the chance to update to the proper id?
+		key := unprotectedHmacSecret
+		if state.Protected.ValueBool() {
+			key = r.secretKey(sHmacSecretKey)
+		}
+		id, err := calculateID(contents, key, state.HmacAlgorithm.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError("Error deleting file: ", err.Error())
+			resp.Diagnostics.AddError("Error reading file: ", "Problem calculating id from key: "+err.Error())
 			return
 		}
+		state.ID = types.StringValue(id)
 	}
 
-	if err := r.client.Delete(directory, name); err != nil {
-		resp.Diagnostics.AddError("Failed to delete file: ", err.Error())
+	// update the state with the actual mode, permissions which describe the same mode are kept
+	state.Permissions = permissions.StateValue(state.Permissions, perm)
+
+	state.Owner = ownership.StateValue(state.Owner, info["Owner"], info["Uid"])
+	state.Group = ownership.StateValue(state.Group, info["Group"], info["Gid"])
+	state.ModifiedTime = timeValue(state.ModifiedTime, info["ModifiedTime"])
+	state.AccessTime = timeValue(state.AccessTime, info["AccessTime"])
+	resp.Diagnostics.Append(r.readXattrs(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	recordDrift(ctx, before, state, resp)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
 }
 
-func (r *LocalResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-}
-
-// **** Internal Functions **** //
+// readDigest updates the state of a file whose contents aren't kept in state without reading it into memory.
+// The hash of the file is compared to the digest in state instead, the digest is one of source_sha256 or contents_sha256.
+func (r *LocalResource) readDigest(ctx context.Context, state *LocalResourceModel, digest *types.String, resp *resource.ReadResponse) {
+	before := *state
+	sName := state.Name.ValueString()
+	sDirectory := state.Directory.ValueString()
 
-// generates an HMAC-SHA256 hash of a file or a string using a secret key.
-func calculateID(contents string, hmacSecretKey string) (string, error) {
-	// If possible, we should avoid reading the file into memory
+	info, err := r.client.Info(sDirectory, sName)
+	if err != nil && err.Error() == "file not found" {
+		warnRemoved(*state, resp)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+		return
+	}
 
-	reader := strings.NewReader(contents)
-	hasher := hmac.New(sha256.New, []byte(hmacSecretKey))
-	// Copy the contents to the hasher without reading it into memory.
-	if _, err := io.Copy(hasher, reader); err != nil {
-		return "", fmt.Errorf("failed to copy file content to hmac hasher: %w", err)
+	client := r.contentsClient(*state)
+	hash, err := client.Hash(sDirectory, sName)
+	if errors.Is(err, encryption.ErrNotEncrypted) {
+		warnNotEncrypted(*state, resp)
+		resp.State.RemoveResource(ctx)
+		return
 	}
-	hmacHash := hex.EncodeToString(hasher.Sum(nil))
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file: ", err.Error())
+		return
+	}
+	r.keepAccessTime(*state, info)
+	if hash != digest.ValueString() {
+		// the file no longer matches what was written
+		*digest = types.StringValue(hash)
+		key := unprotectedHmacSecret
+		if state.Protected.ValueBool() {
+			key = r.secretKey(state.HmacSecretKey.ValueString())
+		}
+		id, err := fileID(client, sDirectory, sName, key, state.HmacAlgorithm.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Error reading file: ", "Problem calculating id from key: "+err.Error())
+			return
+		}
+		state.ID = types.StringValue(id)
+	}
+
+	state.Permissions = permissions.StateValue(state.Permissions, info["Mode"])
+	state.Owner = ownership.StateValue(state.Owner, info["Owner"], info["Uid"])
+	state.Group = ownership.StateValue(state.Group, info["Group"], info["Gid"])
+	state.ModifiedTime = timeValue(state.ModifiedTime, info["ModifiedTime"])
+	state.AccessTime = timeValue(state.AccessTime, info["AccessTime"])
+	resp.Diagnostics.Append(r.readXattrs(ctx, state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	recordDrift(ctx, before, *state, resp)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+}
+
+// For now, we are assuming Terraform has complete control over the file
+// This means we don't need know anything about the actual file for updates, we just change the file if the plan doesn't match the state.
+// The plan has the authority here, state and reality needs to match the plan.
+func (r *LocalResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
+
+	var config LocalResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if writeOnly(config) {
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("contents_wo"), &config.ContentsWo)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	cID := config.ID.ValueString()
+	cName := config.Name.ValueString()
+	cContents, err := rawContents(config)
+	if err != nil {
+		resp.Diagnostics.AddError("Error updating file: ", err.Error())
+		return
+	}
+	cDirectory := config.Directory.ValueString()
+	cPerm := config.Permissions.ValueString()
+	cHmacSecretKey := config.HmacSecretKey.ValueString()
+	cProtected := config.Protected.ValueBool()
+	cSource := config.Source.ValueString()
+
+	cKey := r.secretKey(cHmacSecretKey)
+	if cProtected {
+		// this only validates that the key given was correctly used to generate the id, it doesn't actually protect the file
+		err := r.validateProtectedContents(config, false, cID, cKey)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", err.Error())
+			return
+		}
+	} else {
+		id, err := r.contentsID(config, false, unprotectedHmacSecret)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", "Problem calculating id from hard coded key: "+err.Error())
+			return
+		}
+		config.ID = types.StringValue(id)
+		config.HmacSecretKey = types.StringValue("")
+	}
+
+	// Read updates state with reality, so state = reality
+	var reality LocalResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &reality)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rID := reality.ID.ValueString()
+	rName := reality.Name.ValueString()
+	rDirectory := reality.Directory.ValueString()
+	rHmacSecretKey := reality.HmacSecretKey.ValueString()
+	rProtected := reality.Protected.ValueBool()
+
+	rKey := r.secretKey(rHmacSecretKey)
+	previousKey := config.PreviousHmacSecretKey.ValueString()
+	if rProtected {
+		// if the key was previously coded into the config then this only verifies that it was used to calculate the id properly
+		// if the key is being given in the environment variable, this validates that the given key can calculate the previous id
+		err := r.validateProtectedContents(reality, true, rID, rKey)
+		if err != nil && previousKey != "" {
+			// the key is being rotated, the id in state was calculated with the previous key
+			err = r.validateProtectedContents(reality, true, rID, previousKey)
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", err.Error())
+			return
+		}
+	}
+
+	unlock, err := r.acquireLock(config, rDirectory, rName)
+	if err != nil {
+		resp.Diagnostics.AddError("Error locking file: ", err.Error())
+		return
+	}
+	defer func() { resp.Diagnostics.Append(unlock()...) }()
+
+	unchanged := r.unchanged(config, reality, cContents)
+	config.Backups = types.ListNull(types.StringType)
+	if config.Backup != nil {
+		config.Backups = reality.Backups
+		if config.Backups.IsNull() {
+			config.Backups = types.ListValueMust(types.StringType, []attr.Value{})
+		}
+		if !unchanged {
+			config.Backups, err = r.backup(ctx, config, rDirectory, rName)
+			if err != nil {
+				resp.Diagnostics.AddError("Error backing up file: ", err.Error())
+				return
+			}
+		}
+	}
+	var created []string
+	if !unchanged {
+		if created, err = r.makeDirectory(config, cDirectory); err != nil {
+			resp.Diagnostics.AddError("Error creating directory: ", err.Error())
+			return
+		}
+	}
+	unlockNew := func() diag.Diagnostics { return nil }
+	if filepath.Join(rDirectory, rName) != filepath.Join(cDirectory, cName) {
+		// the file is moving, lock its new path too
+		if unlockNew, err = r.acquireLock(config, cDirectory, cName); err != nil {
+			resp.Diagnostics.AddError("Error locking file: ", err.Error())
+			return
+		}
+		defer func() { resp.Diagnostics.Append(unlockNew()...) }()
+	}
+	switch {
+	case unchanged:
+		// only the id, key, ownership, or times changed, leave the file alone
+	case config.PreserveMtime.ValueBool() && r.sameContents(config, rDirectory, rName, cContents):
+		err = r.client.Move(rDirectory, rName, cDirectory, cName, cPerm)
+	case config.Source.IsNull():
+		err = r.contentsClient(config).Update(rDirectory, rName, cDirectory, cName, cContents, cPerm)
+	default:
+		err = r.client.CreateFrom(cSource, cDirectory, cName, cPerm)
+		if err == nil && filepath.Join(rDirectory, rName) != filepath.Join(cDirectory, cName) {
+			err = r.client.Delete(rDirectory, rName)
+		}
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error updating file: ", err.Error())
+		return
+	}
+	// the contents are written, a lock file has to be gone before its directory can be removed
+	resp.Diagnostics.Append(unlock()...)
+	resp.Diagnostics.Append(unlockNew()...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config.CreatedDirectories = types.ListNull(types.StringType)
+	if config.CreateParentDirs.ValueBool() {
+		var directories []string
+		resp.Diagnostics.Append(reality.CreatedDirectories.ElementsAs(ctx, &directories, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if filepath.Clean(rDirectory) != filepath.Clean(cDirectory) {
+			// the file moved, the directories created for it may be empty now
+			if directories, err = r.removeDirectories(directories); err != nil {
+				resp.Diagnostics.AddError("Error removing directory: ", err.Error())
+				return
+			}
+		}
+		if config.CreatedDirectories, err = stringList(ctx, append(directories, created...)); err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", err.Error())
+			return
+		}
+	}
+	if !config.Source.IsNull() && config.SourceSha256.IsUnknown() {
+		// the source wasn't known at plan time
+		hash, err := r.client.Hash(cDirectory, cName)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", err.Error())
+			return
+		}
+		config.SourceSha256 = types.StringValue(hash)
+	}
+	if writeOnly(config) || (hashOnly(config) && config.Source.IsNull()) {
+		hash, err := textDigest(config, cContents)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating file: ", err.Error())
+			return
+		}
+		config.ContentsSha256 = types.StringValue(hash)
+	}
+	config.ContentsWo = types.StringNull()
+	if err = r.applyOwnership(&config); err != nil {
+		resp.Diagnostics.AddError("Error updating file: ", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(r.applyXattrs(ctx, config, reality.Xattrs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err = r.applyTimes(config); err != nil {
+		resp.Diagnostics.AddError("Error setting file times: ", err.Error())
+		return
+	}
+
+	if resp.Private != nil {
+		// the file matches the plan again, see recordDrift
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, driftKey, nil)...)
+	}
+
+	// the path, mode, and contents are all of the "real" parts of the file
+	// the id is calculated from the secret key and contents,
+	//   so if the config's id is correct, then its key is correct
+	//   and there isn't anything to change in reality
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+}
+
+func (r *LocalResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
+
+	var state LocalResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := state.Name.ValueString()
+	directory := state.Directory.ValueString()
+
+	protected := state.Protected.ValueBool()
+	id := state.ID.ValueString()
+	key := r.secretKey(state.HmacSecretKey.ValueString())
+
+	// we need to validate the id before we can delete a protected file, retained files aren't changed
+	if protected && state.DestroyBehavior.ValueString() != "retain" {
+		err := r.validateProtectedContents(state, true, id, key)
+		if err != nil {
+			resp.Diagnostics.AddError("Error deleting file: ", err.Error())
+			return
+		}
+	}
+
+	unlock, err := r.acquireLock(state, directory, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Error locking file: ", err.Error())
+		return
+	}
+	defer func() { resp.Diagnostics.Append(unlock()...) }()
+
+	if state.Backup != nil && state.DestroyBehavior.ValueString() != "retain" {
+		if _, err := r.backup(ctx, state, directory, name); err != nil {
+			resp.Diagnostics.AddError("Error backing up file: ", err.Error())
+			return
+		}
+	}
+
+	switch state.DestroyBehavior.ValueString() {
+	case "retain":
+		tflog.Debug(ctx, "Retaining file, it is only removed from state.")
+	case "restore":
+		r.restoreOriginal(ctx, directory, name, req, resp)
+	default:
+		if err := r.client.Delete(directory, name); err != nil {
+			resp.Diagnostics.AddError("Failed to delete file: ", err.Error())
+			return
+		}
+	}
+	// a lock file has to be gone before its directory can be removed
+	resp.Diagnostics.Append(unlock()...)
+	if resp.Diagnostics.HasError() || state.DestroyBehavior.ValueString() == "retain" {
+		return
+	}
+
+	var directories []string
+	resp.Diagnostics.Append(state.CreatedDirectories.ElementsAs(ctx, &directories, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.removeDirectories(directories); err != nil {
+		resp.Diagnostics.AddError("Failed to delete directory: ", err.Error())
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+}
+
+// ImportState adopts an existing file, the import id is the path to the file with an optional ",protected" suffix.
+// The id is calculated from the contents on disk, protected files use the key from the provider or the environment.
+func (r *LocalResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	tflog.Debug(ctx, fmt.Sprintf("Request Object: %#v", req))
+
+	filePath, protected := strings.CutSuffix(req.ID, ",protected")
+	if filePath == "" {
+		resp.Diagnostics.AddError("Error importing file: ", "expected the path to the file, eg. '/path/to/file' or '/path/to/file,protected'")
+		return
+	}
+	filePath = filepath.Clean(filePath)
+	if r.providerData != nil {
+		if err := r.providerData.Sandbox.Check(filePath); err != nil {
+			resp.Diagnostics.AddError("Error importing file: ", err.Error())
+			return
+		}
+	}
+	directory := filepath.Dir(filePath)
+	name := filepath.Base(filePath)
+
+	perm, contents, err := r.client.Read(directory, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Error importing file: ", err.Error())
+		return
+	}
+
+	key := unprotectedHmacSecret
+	if protected {
+		key = r.secretKey("")
+		if key == "" {
+			resp.Diagnostics.AddError("Error importing file: ",
+				"importing a protected file requires the provider's 'hmac_secret_key' or the TF_FILE_HMAC_SECRET_KEY environment variable")
+			return
+		}
+	}
+	id, err := calculateID(contents, key, defaultHmacAlgorithm)
+	if err != nil {
+		resp.Diagnostics.AddError("Error importing file: ", "Problem calculating id from key: "+err.Error())
+		return
+	}
+
+	state := LocalResourceModel{
+		ID:                    types.StringValue(id),
+		Name:                  types.StringValue(name),
+		Directory:             types.StringValue(directory),
+		Contents:              types.StringNull(),
+		ContentsBase64:        types.StringNull(),
+		Permissions:           types.StringValue(perm),
+		ContentsWo:            types.StringNull(),
+		ContentsWoVersion:     types.Int64Null(),
+		Source:                types.StringNull(),
+		SourceSha256:          types.StringNull(),
+		StoreContents:         types.BoolValue(true),
+		ContentsSha256:        types.StringNull(),
+		HmacSecretKey:         types.StringValue(""),
+		PreviousHmacSecretKey: types.StringNull(),
+		HmacAlgorithm:         types.StringValue(defaultHmacAlgorithm),
+		Protected:             types.BoolValue(protected),
+		DestroyBehavior:       types.StringValue("delete"),
+		IfExists:              types.StringValue("overwrite"),
+		CreateDirectories:     types.BoolNull(),
+		CreateParentDirs:      types.BoolValue(false),
+		CreatedDirectories:    types.ListNull(types.StringType),
+		PreserveMtime:         types.BoolValue(false),
+		Backups:               types.ListNull(types.StringType),
+		Xattrs:                types.MapNull(types.StringType),
+		SelinuxContext:        types.StringNull(),
+		LineEndings:           types.StringValue("preserve"),
+		Encoding:              types.StringValue("utf-8"),
+	}
+	// Terraform strings must be valid UTF-8, binary files are imported into contents_base64
+	if utf8.ValidString(contents) {
+		state.Contents = types.StringValue(contents)
+	} else {
+		state.ContentsBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(contents)))
+	}
+	info, err := r.client.Info(directory, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Error importing file: ", err.Error())
+		return
+	}
+	state.Owner = ownership.Value(info["Owner"], info["Uid"])
+	state.Group = ownership.Value(info["Group"], info["Gid"])
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
+}
+
+// localFileState is the part of the state of the hashicorp/local provider's local_file and local_sensitive_file resources which is moved.
+// Only local_file has sensitive_content, it is the deprecated way to give sensitive contents.
+type localFileState struct {
+	Filename            string  `json:"filename"`
+	Content             *string `json:"content"`
+	SensitiveContent    *string `json:"sensitive_content"`
+	ContentBase64       *string `json:"content_base64"`
+	Source              *string `json:"source"`
+	FilePermission      string  `json:"file_permission"`
+	DirectoryPermission string  `json:"directory_permission"`
+}
+
+// MoveState lets a moved block move a local_file or local_sensitive_file from the hashicorp/local provider to a file_local,
+// without removing it from the state and importing it.
+func (r *LocalResource) MoveState(_ context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		{StateMover: r.moveLocalFile},
+	}
+}
+
+// moveLocalFile translates the state of a local_file or local_sensitive_file, other resources are left for the framework to reject.
+// The file is unprotected after the move, the id is calculated from the contents with the hard coded key.
+// local_file always creates the parent directories, so the moved file keeps doing that with the same permissions.
+func (r *LocalResource) moveLocalFile(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if !strings.HasSuffix(req.SourceProviderAddress, "hashicorp/local") ||
+		(req.SourceTypeName != "local_file" && req.SourceTypeName != "local_sensitive_file") {
+		return
+	}
+	if req.SourceRawState == nil {
+		resp.Diagnostics.AddError("Error moving file: ", "the state of the "+req.SourceTypeName+" is empty")
+		return
+	}
+	var source localFileState
+	if err := json.Unmarshal(req.SourceRawState.JSON, &source); err != nil {
+		resp.Diagnostics.AddError("Error moving file: ", "Problem reading the state of the "+req.SourceTypeName+": "+err.Error())
+		return
+	}
+	if source.Filename == "" {
+		resp.Diagnostics.AddError("Error moving file: ", "the "+req.SourceTypeName+" doesn't have a filename")
+		return
+	}
+	filePath := filepath.Clean(source.Filename)
+	if r.providerData != nil {
+		if err := r.providerData.Sandbox.Check(filePath); err != nil {
+			resp.Diagnostics.AddError("Error moving file: ", err.Error())
+			return
+		}
+	}
+	directory := filepath.Dir(filePath)
+	name := filepath.Base(filePath)
+
+	state := LocalResourceModel{
+		Name:                  types.StringValue(name),
+		Directory:             types.StringValue(directory),
+		Contents:              types.StringNull(),
+		ContentsBase64:        types.StringNull(),
+		Permissions:           types.StringValue(source.FilePermission),
+		ContentsWo:            types.StringNull(),
+		ContentsWoVersion:     types.Int64Null(),
+		Source:                types.StringNull(),
+		SourceSha256:          types.StringNull(),
+		StoreContents:         types.BoolValue(true),
+		ContentsSha256:        types.StringNull(),
+		Owner:                 types.StringNull(),
+		Group:                 types.StringNull(),
+		HmacSecretKey:         types.StringValue(""),
+		PreviousHmacSecretKey: types.StringNull(),
+		HmacAlgorithm:         types.StringValue(defaultHmacAlgorithm),
+		Protected:             types.BoolValue(false),
+		DestroyBehavior:       types.StringValue("delete"),
+		IfExists:              types.StringValue("overwrite"),
+		CreateDirectories:     types.BoolNull(),
+		CreateParentDirs:      types.BoolValue(true),
+		DirectoryPermissions:  types.StringNull(),
+		CreatedDirectories:    types.ListNull(types.StringType),
+		PreserveMtime:         types.BoolValue(false),
+		Backups:               types.ListNull(types.StringType),
+		Xattrs:                types.MapNull(types.StringType),
+		SelinuxContext:        types.StringNull(),
+		LineEndings:           types.StringValue("preserve"),
+		Encoding:              types.StringValue("utf-8"),
+	}
+	if source.FilePermission == "" {
+		// this is the local_file default, old versions of the local provider didn't record it
+		state.Permissions = types.StringValue("0777")
+	}
+	if source.DirectoryPermission != "" {
+		state.DirectoryPermissions = types.StringValue(source.DirectoryPermission)
+	}
+
+	var id string
+	var err error
+	switch {
+	case source.Content != nil:
+		state.Contents = types.StringValue(*source.Content)
+		id, err = calculateID(*source.Content, unprotectedHmacSecret, defaultHmacAlgorithm)
+	case source.SensitiveContent != nil:
+		state.Contents = types.StringValue(*source.SensitiveContent)
+		id, err = calculateID(*source.SensitiveContent, unprotectedHmacSecret, defaultHmacAlgorithm)
+	case source.ContentBase64 != nil:
+		state.ContentsBase64 = types.StringValue(*source.ContentBase64)
+		var contents string
+		if contents, err = rawContents(state); err == nil {
+			id, err = calculateID(contents, unprotectedHmacSecret, defaultHmacAlgorithm)
+		}
+	case source.Source != nil:
+		// the contents of the source are only on disk, so the id and hash come from the file which was written
+		state.Source = types.StringValue(*source.Source)
+		var hash string
+		if hash, err = r.client.Hash(directory, name); err == nil {
+			state.SourceSha256 = types.StringValue(hash)
+			id, err = fileID(r.client, directory, name, unprotectedHmacSecret, defaultHmacAlgorithm)
+		}
+	default:
+		err = fmt.Errorf("the %s doesn't have content, sensitive_content, content_base64, or source", req.SourceTypeName)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error moving file: ", err.Error())
+		return
+	}
+	state.ID = types.StringValue(id)
+
+	// the owner and group are read when the file exists, Read removes a file which doesn't from the state
+	if info, err := r.client.Info(directory, name); err == nil {
+		state.Owner = ownership.Value(info["Owner"], info["Uid"])
+		state.Group = ownership.Value(info["Group"], info["Gid"])
+	}
+
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, &state)...)
+}
+
+// **** Internal Functions **** //
+
+// secretKey returns the hmac secret key to use for a file.
+// The resource argument takes precedence, then the provider configuration, then the TF_FILE_HMAC_SECRET_KEY environment variable.
+func (r *LocalResource) secretKey(hmacSecretKey string) string {
+	if hmacSecretKey != "" {
+		return hmacSecretKey
+	}
+	if r.providerData != nil && r.providerData.HmacSecretKey != "" {
+		return r.providerData.HmacSecretKey
+	}
+	return os.Getenv("TF_FILE_HMAC_SECRET_KEY")
+}
+
+// rawContents returns the bytes which belong in the file.
+// Terraform strings must be valid UTF-8, so binary data is given base64 encoded in contents_base64 and decoded here.
+func rawContents(data LocalResourceModel) (string, error) {
+	if !data.ContentsWo.IsNull() {
+		return data.ContentsWo.ValueString(), nil
+	}
+	if data.ContentsBase64.IsNull() || data.ContentsBase64.IsUnknown() {
+		return data.Contents.ValueString(), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data.ContentsBase64.ValueString())
+	if err != nil {
+		return "", fmt.Errorf("contents_base64 isn't valid base64: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// openContents returns a reader for the bytes which belong in the file, the contents come from the model when possible.
+// For a plan, files created from a source stream the source.
+// For a state, files which don't keep their contents in state stream the file on disk, Read makes sure the file matches the state.
+func (r *LocalResource) openContents(data LocalResourceModel, isState bool) (io.ReadCloser, error) {
+	switch {
+	case isState && (!data.Source.IsNull() || hashOnly(data) || writeOnly(data)):
+		return r.contentsClient(data).Open(data.Directory.ValueString(), data.Name.ValueString())
+	case !data.Source.IsNull():
+		source := data.Source.ValueString()
+		return r.client.Open(filepath.Dir(source), filepath.Base(source))
+	}
+	contents, err := rawContents(data)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(contents)), nil
+}
+
+// applyOwnership gives the file to the configured owner and group, then saves the actual owner and group in the model.
+func (r *LocalResource) applyOwnership(data *LocalResourceModel) error {
+	directory := data.Directory.ValueString()
+	name := data.Name.ValueString()
+	owner := data.Owner.ValueString() // unknown and null values are empty strings
+	group := data.Group.ValueString()
+	if owner != "" || group != "" {
+		if err := r.client.Chown(directory, name, owner, group); err != nil {
+			return err
+		}
+	}
+	info, err := r.client.Info(directory, name)
+	if err != nil {
+		return err
+	}
+	data.Owner = ownership.StateValue(data.Owner, info["Owner"], info["Uid"])
+	data.Group = ownership.StateValue(data.Group, info["Group"], info["Gid"])
+	return nil
+}
+
+// acquireLock takes the advisory lock configured by the lock block, without the block nothing is locked.
+// The returned function releases the lock, it only releases it once so it can be deferred and also called early.
+func (r *LocalResource) acquireLock(data LocalResourceModel, directory string, name string) (func() diag.Diagnostics, error) {
+	if data.Lock == nil {
+		return func() diag.Diagnostics { return nil }, nil
+	}
+	mode := data.Lock.Mode.ValueString()
+	if mode == "" {
+		mode = lock.Flock
+	}
+	timeout, err := lock.ParseTimeout(data.Lock.Timeout.ValueString())
+	if err != nil {
+		return nil, err
+	}
+	release, err := r.client.Lock(directory, name, mode, timeout)
+	if err != nil {
+		return nil, err
+	}
+	released := false
+	return func() diag.Diagnostics {
+		var diags diag.Diagnostics
+		if released {
+			return diags
+		}
+		released = true
+		if err := release(); err != nil {
+			diags.AddError("Error releasing lock: ", err.Error())
+		}
+		return diags
+	}, nil
+}
+
+// unchanged reports whether the file on disk already has the planned path, permissions, encryption, and contents.
+// A file which can't be hashed is treated as changed, so it is written.
+func (r *LocalResource) unchanged(plan LocalResourceModel, reality LocalResourceModel, contents string) bool {
+	if plan.Directory.ValueString() != reality.Directory.ValueString() ||
+		plan.Name.ValueString() != reality.Name.ValueString() ||
+		!permissions.Equal(plan.Permissions.ValueString(), reality.Permissions.ValueString()) ||
+		!sameEncryption(plan.Encryption, reality.Encryption) {
+		return false
+	}
+	return r.sameContents(plan, reality.Directory.ValueString(), reality.Name.ValueString(), contents)
+}
+
+// sameContents reports whether the file on disk already has the planned contents, after they are converted by 'line_endings' and 'encoding'.
+func (r *LocalResource) sameContents(plan LocalResourceModel, directory string, name string, contents string) bool {
+	encoded, err := c.EncodeText(contents, plan.LineEndings.ValueString(), plan.Encoding.ValueString())
+	if err != nil {
+		return false
+	}
+	want := sha256Hex(encoded)
+	if !plan.Source.IsNull() {
+		if plan.SourceSha256.IsUnknown() {
+			return false
+		}
+		want = plan.SourceSha256.ValueString()
+	}
+	got, err := r.encryptedClient(plan).Hash(directory, name)
+	return err == nil && got == want
+}
+
+// sameEncryption reports whether the file is encrypted the same way, a file encrypted for other recipients or with another key is rewritten.
+// Keys from the provider configuration or the environment aren't compared, a file which the new key can't decrypt is rewritten anyway.
+func sameEncryption(plan *LocalEncryptionModel, reality *LocalEncryptionModel) bool {
+	if plan == nil || reality == nil {
+		return plan == reality
+	}
+	return plan.Recipients.Equal(reality.Recipients) && plan.Key.Equal(reality.Key)
+}
+
+// contentsClient returns the client which converts the contents with the 'line_endings' and 'encoding' of the file,
+// then encrypts them with the encryption block. Files which are written as they are use the client directly.
+func (r *LocalResource) contentsClient(data LocalResourceModel) c.FileClient {
+	client := r.encryptedClient(data)
+	lineEndings := data.LineEndings.ValueString()
+	encoding := data.Encoding.ValueString()
+	if (lineEndings == "" || lineEndings == "preserve") && (encoding == "" || encoding == "utf-8") {
+		return client
+	}
+	return &c.TextFileClient{FileClient: client, LineEndings: lineEndings, Encoding: encoding}
+}
+
+// encryptedClient returns the client which encrypts the file with the encryption block, without the block the client is used directly.
+func (r *LocalResource) encryptedClient(data LocalResourceModel) c.FileClient {
+	if data.Encryption == nil {
+		return r.client
+	}
+	var recipients []string
+	for _, recipient := range data.Encryption.Recipients.Elements() {
+		if value, ok := recipient.(types.String); ok {
+			recipients = append(recipients, value.ValueString())
+		}
+	}
+	return &c.EncryptedFileClient{
+		FileClient: r.client,
+		Recipients: recipients,
+		Key:        encryptionKey(r.providerData, data.Encryption.Key.ValueString()),
+		Identity:   ageIdentity(r.providerData, data.Encryption.Identity.ValueString()),
+	}
+}
+
+// encryptionKey returns the AES-256 key to use for a file.
+// The argument takes precedence, then the provider configuration, then the TF_FILE_ENCRYPTION_KEY environment variable.
+func encryptionKey(providerData *provider_data.ProviderData, key string) string {
+	if key != "" {
+		return key
+	}
+	if providerData != nil && providerData.EncryptionKey != "" {
+		return providerData.EncryptionKey
+	}
+	return os.Getenv("TF_FILE_ENCRYPTION_KEY")
+}
+
+// ageIdentity returns the age identity which decrypts a file.
+// The argument takes precedence, then the provider configuration, then the TF_FILE_AGE_IDENTITY environment variable.
+func ageIdentity(providerData *provider_data.ProviderData, identity string) string {
+	if identity != "" {
+		return identity
+	}
+	if providerData != nil && providerData.AgeIdentity != "" {
+		return providerData.AgeIdentity
+	}
+	return os.Getenv("TF_FILE_AGE_IDENTITY")
+}
+
+// sameText reports whether the decoded contents of the file are what the contents in state read back as.
+// Converting line endings replaces them, so contents with CRLF line endings read back with LF line endings.
+func (r *LocalResource) sameText(state LocalResourceModel, decoded string, contents string) bool {
+	lineEndings := state.LineEndings.ValueString()
+	encoding := state.Encoding.ValueString()
+	encoded, err := c.EncodeText(contents, lineEndings, encoding)
+	if err != nil {
+		return false
+	}
+	written, err := c.DecodeText(encoded, lineEndings, encoding)
+	return err == nil && written == decoded
+}
+
+// applyTimes gives the file the configured modification and access times, null times are left alone.
+func (r *LocalResource) applyTimes(data LocalResourceModel) error {
+	modified := data.ModifiedTime.ValueString()
+	accessed := data.AccessTime.ValueString()
+	if modified == "" && accessed == "" {
+		return nil
+	}
+	return r.client.SetTimes(data.Directory.ValueString(), data.Name.ValueString(), modified, accessed)
+}
+
+// applyXattrs gives the file the configured extended attributes and SELinux context.
+// The previous attributes are the ones in state, those which are no longer configured are removed.
+func (r *LocalResource) applyXattrs(ctx context.Context, data LocalResourceModel, previous types.Map) diag.Diagnostics {
+	set, remove, diags := xattr.Changes(ctx, data.Xattrs, data.SelinuxContext, previous)
+	if diags.HasError() || (len(set) == 0 && len(remove) == 0) {
+		return diags
+	}
+	if err := r.client.SetXattrs(data.Directory.ValueString(), data.Name.ValueString(), set, remove); err != nil {
+		diags.AddError("Error setting extended attributes: ", err.Error())
+	}
+	return diags
+}
+
+// readXattrs updates the managed extended attributes and SELinux context in state, the file isn't asked when neither is managed.
+func (r *LocalResource) readXattrs(ctx context.Context, state *LocalResourceModel) diag.Diagnostics {
+	if state.Xattrs.IsNull() && state.SelinuxContext.IsNull() {
+		return nil
+	}
+	var diags diag.Diagnostics
+	actual, err := r.client.Xattrs(state.Directory.ValueString(), state.Name.ValueString())
+	if err != nil {
+		diags.AddError("Error reading extended attributes: ", err.Error())
+		return diags
+	}
+	state.Xattrs, diags = xattr.StateValue(ctx, state.Xattrs, actual)
+	state.SelinuxContext = xattr.SELinuxValue(state.SelinuxContext, actual)
+	return diags
+}
+
+// keepAccessTime puts back the access time from before the provider read the file, so refreshing isn't an access.
+// This is best effort, it only matters when 'access_time' is managed.
+func (r *LocalResource) keepAccessTime(state LocalResourceModel, info map[string]string) {
+	if state.AccessTime.IsNull() || info["AccessTime"] == "" {
+		return
+	}
+	_ = r.client.SetTimes(state.Directory.ValueString(), state.Name.ValueString(), "", info["AccessTime"])
+}
+
+// timeValue returns the time to save in state after reading a file, only times which are managed are saved.
+// The current value is kept when it's the same second, so the format given by the user never shows up as a difference.
+func timeValue(current types.String, actual string) types.String {
+	if current.IsNull() || current.IsUnknown() || actual == "" {
+		return current
+	}
+	got, err := time.Parse(time.RFC3339Nano, actual)
+	if err != nil {
+		return current
+	}
+	want, err := time.Parse(time.RFC3339Nano, current.ValueString())
+	if err == nil && want.Truncate(time.Second).Equal(got.Truncate(time.Second)) {
+		return current
+	}
+	return types.StringValue(actual)
+}
+
+// requiresReplaceUnlessRotating replaces the file when the hmac secret key changes, unless the previous key is given.
+func requiresReplaceUnlessRotating(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	var previousKey types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("previous_hmac_secret_key"), &previousKey)...)
+	resp.RequiresReplace = previousKey.IsNull() || previousKey.ValueString() == ""
+}
+
+// makeDirectory creates the missing parents of the file when 'create_parent_directories' is true,
+// it returns the directories which were created.
+func (r *LocalResource) makeDirectory(data LocalResourceModel, directory string) ([]string, error) {
+	if !data.CreateParentDirs.ValueBool() {
+		return nil, nil
+	}
+	permissions := data.DirectoryPermissions.ValueString()
+	if permissions == "" && r.providerData != nil {
+		permissions = r.providerData.DefaultDirectoryPermissions
+	}
+	if permissions == "" {
+		permissions = "0700"
+	}
+	return r.client.MakeDirectory(directory, permissions)
+}
+
+// removeDirectories removes the directories created for the file, deepest first.
+// Directories which aren't empty are left alone, they are returned so they can be tried again later.
+func (r *LocalResource) removeDirectories(directories []string) ([]string, error) {
+	var kept []string
+	for i := len(directories) - 1; i >= 0; i-- {
+		removed, err := r.client.DeleteDirectory(directories[i])
+		if err != nil {
+			return nil, err
+		}
+		if !removed {
+			kept = append([]string{directories[i]}, kept...)
+		}
+	}
+	return kept, nil
+}
+
+// backup copies the file before it is changed, see the 'backup' block.
+// Only the backups in the data's 'backups' are pruned, so files which weren't made by this resource are never removed.
+// A file which is already gone has nothing to back up, the list is empty.
+func (r *LocalResource) backup(ctx context.Context, data LocalResourceModel, directory string, name string) (types.List, error) {
+	var previous []string
+	if diags := data.Backups.ElementsAs(ctx, &previous, false); diags.HasError() {
+		return types.ListNull(types.StringType), fmt.Errorf("problem reading the previous backups: %v", diags)
+	}
+	backups, err := r.client.Backup(directory, name, int(data.Backup.Count.ValueInt64()), data.Backup.SuffixFormat.ValueString(), previous)
+	if err != nil && err.Error() != "file not found" {
+		return types.ListNull(types.StringType), err
+	}
+	return stringList(ctx, backups)
+}
+
+// stringList converts paths to a list attribute, no paths is an empty list rather than null.
+func stringList(ctx context.Context, values []string) (types.List, error) {
+	if values == nil {
+		values = []string{}
+	}
+	list, diags := types.ListValueFrom(ctx, types.StringType, values)
+	if diags.HasError() {
+		return types.ListNull(types.StringType), fmt.Errorf("problem saving paths: %v", diags)
+	}
+	return list, nil
+}
+
+// captureOriginal copies the file which is at the path before it is created, so Delete can restore it, see copyOriginal.
+// The original is returned so Create can put it back when it fails.
+func (r *LocalResource) captureOriginal(ctx context.Context, directory string, name string, resp *resource.CreateResponse) originalFile {
+	original, err := r.copyOriginal(directory, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Error capturing original file: ", err.Error())
+		return original
+	}
+	value, err := json.Marshal(original)
+	if err != nil {
+		resp.Diagnostics.AddError("Error capturing original file: ", err.Error())
+		return original
+	}
+	if resp.Private == nil {
+		// Terraform always gives Create a private state, it is only missing when Create is called directly
+		return original
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, originalKey, value)...)
+	return original
+}
+
+// copyOriginal copies the file at the path to a backup next to it with the same permissions and ownership.
+// Only the backup's name and hash are returned for private state, with the permissions of the original.
+// An existing backup is only reused when it matches the file, eg. when an earlier Create failed before it could clean up.
+// Giving the copy the original's owner is best effort, only a privileged user can give a file away.
+func (r *LocalResource) copyOriginal(directory string, name string) (originalFile, error) {
+	original := originalFile{Directory: directory, Name: name}
+	info, err := r.client.Info(directory, name)
+	if err != nil && err.Error() == "file not found" {
+		return original, nil
+	}
+	if err != nil {
+		return original, err
+	}
+	original.Exists = true
+	original.Backup = name + originalSuffix
+	original.Permissions = info["Mode"]
+	if original.Sha256, err = r.client.Hash(directory, name); err != nil {
+		return original, err
+	}
+	_, err = r.client.Info(directory, original.Backup)
+	if err != nil && err.Error() != "file not found" {
+		return original, err
+	}
+	if err == nil {
+		backupHash, err := r.client.Hash(directory, original.Backup)
+		if err != nil {
+			return original, err
+		}
+		if backupHash == original.Sha256 {
+			return original, nil
+		}
+		return original, fmt.Errorf("the file '%s' already exists and doesn't match '%s', move it away so the original file can be copied there",
+			filepath.Join(directory, original.Backup), filepath.Join(directory, name))
+	}
+	if err := r.client.CreateFrom(filepath.Join(directory, name), directory, original.Backup, original.Permissions); err != nil {
+		return original, err
+	}
+	_ = r.client.Chown(directory, original.Backup, info["Uid"], info["Gid"])
+	return original, nil
+}
+
+// restoreOriginal puts back the file captured by captureOriginal, see putBackOriginal.
+func (r *LocalResource) restoreOriginal(ctx context.Context, directory string, name string, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	value, diags := req.Private.GetKey(ctx, originalKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var original originalFile
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &original); err != nil {
+			resp.Diagnostics.AddError("Error restoring original file: ", err.Error())
+			return
+		}
+	}
+	if err := r.putBackOriginal(original, directory, name); err != nil {
+		resp.Diagnostics.AddError("Error restoring original file: ", err.Error())
+	}
+}
+
+// putBackOriginal moves the copy made by copyOriginal back over the file, the managed file is deleted when nothing was copied.
+// The copy is checked against its hash before the managed file is touched, so a failed restore leaves everything in place.
+// The rename keeps the owner of the copy, which is the owner of the original.
+func (r *LocalResource) putBackOriginal(original originalFile, directory string, name string) error {
+	if original.Exists {
+		hash, err := r.client.Hash(original.Directory, original.Backup)
+		if err != nil {
+			return err
+		}
+		if hash != original.Sha256 {
+			return fmt.Errorf("the copy of the original file '%s' was changed, it no longer has the sha256 hash %s",
+				filepath.Join(original.Directory, original.Backup), original.Sha256)
+		}
+	}
+	if !original.Exists || filepath.Join(original.Directory, original.Name) != filepath.Join(directory, name) {
+		if err := r.client.Delete(directory, name); err != nil {
+			return fmt.Errorf("problem deleting the file: %v", err)
+		}
+	}
+	if !original.Exists {
+		return nil
+	}
+	return r.client.Move(original.Directory, original.Backup, original.Directory, original.Name, original.Permissions)
+}
+
+// driftedAttributes lists the attributes which changed between the state and the file on disk.
+// The digests of files which don't keep their contents in state are reported as contents.
+func driftedAttributes(state LocalResourceModel, reality LocalResourceModel) []string {
+	drifted := []string{}
+	if !state.Contents.Equal(reality.Contents) || !state.ContentsBase64.Equal(reality.ContentsBase64) ||
+		!state.SourceSha256.Equal(reality.SourceSha256) || !state.ContentsSha256.Equal(reality.ContentsSha256) {
+		drifted = append(drifted, "contents")
+	}
+	if !state.Permissions.Equal(reality.Permissions) {
+		drifted = append(drifted, "permissions")
+	}
+	if !state.Owner.Equal(reality.Owner) {
+		drifted = append(drifted, "owner")
+	}
+	if !state.Group.Equal(reality.Group) {
+		drifted = append(drifted, "group")
+	}
+	if !state.ModifiedTime.Equal(reality.ModifiedTime) {
+		drifted = append(drifted, "modified_time")
+	}
+	if !state.AccessTime.Equal(reality.AccessTime) {
+		drifted = append(drifted, "access_time")
+	}
+	if !state.Xattrs.Equal(reality.Xattrs) {
+		drifted = append(drifted, "xattrs")
+	}
+	if !state.SelinuxContext.Equal(reality.SelinuxContext) {
+		drifted = append(drifted, "selinux_context")
+	}
+	return drifted
+}
+
+// recordDrift saves the attributes which Read found changed on disk in the private state, ModifyPlan warns about them.
+// The state is overwritten with reality by Read, so this is the only place the plan can learn what changed.
+func recordDrift(ctx context.Context, state LocalResourceModel, reality LocalResourceModel, resp *resource.ReadResponse) {
+	if resp.Private == nil {
+		// Terraform always gives Read a private state, it is only missing when Read is called directly
+		return
+	}
+	var value []byte
+	if drifted := driftedAttributes(state, reality); len(drifted) > 0 {
+		var err error
+		value, err = json.Marshal(drifted)
+		if err != nil {
+			resp.Diagnostics.AddError("Error saving drift: ", err.Error())
+			return
+		}
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, driftKey, value)...)
+}
+
+// warnRemoved tells the user that a file in state no longer exists, the plan will create it again.
+func warnRemoved(state LocalResourceModel, resp *resource.ReadResponse) {
+	resp.Diagnostics.AddWarning(
+		"File removed outside of Terraform",
+		fmt.Sprintf("The file '%s' was removed or moved outside of Terraform, it is no longer in the state.",
+			filepath.Join(state.Directory.ValueString(), state.Name.ValueString())),
+	)
+}
+
+// warnNotEncrypted tells the user that an encrypted file in state was replaced by an unencrypted file, the plan will replace it.
+func warnNotEncrypted(state LocalResourceModel, resp *resource.ReadResponse) {
+	resp.Diagnostics.AddWarning(
+		"File isn't encrypted",
+		fmt.Sprintf("The file '%s' was replaced by an unencrypted file outside of Terraform, it is no longer in the state.",
+			filepath.Join(state.Directory.ValueString(), state.Name.ValueString())),
+	)
+}
+
+// fileID calculates the id of the file on disk without reading it into memory.
+func fileID(client c.FileClient, directory string, name string, hmacSecretKey string, algorithm string) (string, error) {
+	reader, err := client.Open(directory, name)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	return calculateReaderID(reader, hmacSecretKey, algorithm)
+}
+
+// hashOnly reports whether the file's contents are kept out of the state, a null store_contents means the default of true.
+func hashOnly(data LocalResourceModel) bool {
+	return !data.StoreContents.IsNull() && !data.StoreContents.ValueBool()
+}
+
+// writeOnly reports whether the file's contents are given in 'contents_wo'.
+// Write-only values are null in plans and states, so this is true when none of the other contents arguments are set.
+func writeOnly(data LocalResourceModel) bool {
+	return data.Contents.IsNull() && data.ContentsBase64.IsNull() && data.Source.IsNull()
+}
+
+// textDigest returns the hash of the contents as they read back from the file, which is what Read compares to 'contents_sha256'.
+// 'line_endings' and 'encoding' change the bytes on disk and Read decodes them again, so "\r\n" reads back as "\n" with 'crlf' line endings.
+func textDigest(data LocalResourceModel, contents string) (string, error) {
+	lineEndings := data.LineEndings.ValueString()
+	encoding := data.Encoding.ValueString()
+	encoded, err := c.EncodeText(contents, lineEndings, encoding)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := c.DecodeText(encoded, lineEndings, encoding)
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(decoded), nil
+}
+
+// sha256Hex returns the hex encoded sha256 hash of the contents, matching the file client's Hash.
+func sha256Hex(contents string) string {
+	hash := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(hash[:])
+}
+
+// contentsID calculates the id of the file described by the model, see openContents.
+func (r *LocalResource) contentsID(data LocalResourceModel, isState bool, hmacSecretKey string) (string, error) {
+	reader, err := r.openContents(data, isState)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	return calculateReaderID(reader, hmacSecretKey, data.HmacAlgorithm.ValueString())
+}
+
+// validateProtectedContents validates the id of the file described by the model, see openContents.
+func (r *LocalResource) validateProtectedContents(data LocalResourceModel, isState bool, id string, hmacSecretKey string) error {
+	reader, err := r.openContents(data, isState)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return validateProtected(data.Protected.ValueBool(), id, hmacSecretKey, data.HmacAlgorithm.ValueString(), reader)
+}
+
+// generates an HMAC hash of a file or a string using a secret key.
+func calculateID(contents string, hmacSecretKey string, algorithm string) (string, error) {
+	return calculateReaderID(strings.NewReader(contents), hmacSecretKey, algorithm)
+}
+
+// calculateReaderID generates the HMAC hash of everything in the reader, without reading it all into memory.
+// An empty algorithm means the default, files created before 'hmac_algorithm' existed don't have one in state.
+func calculateReaderID(reader io.Reader, hmacSecretKey string, algorithm string) (string, error) {
+	hashFunc, ok := hmacAlgorithms[hmacAlgorithmName(algorithm)]
+	if !ok {
+		return "", fmt.Errorf("unsupported hmac algorithm '%s'", algorithm)
+	}
+	hasher := hmac.New(hashFunc, []byte(hmacSecretKey))
+	// Copy the contents to the hasher without reading it into memory.
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", fmt.Errorf("failed to copy file content to hmac hasher: %w", err)
+	}
+	hmacHash := hex.EncodeToString(hasher.Sum(nil))
 	return hmacHash, nil
 }
 
-func validateProtected(protected bool, id string, hmacSecretKey string, contents string) error {
+// hmacAlgorithmNames lists the supported values of 'hmac_algorithm'.
+func hmacAlgorithmNames() []string {
+	names := make([]string, 0, len(hmacAlgorithms))
+	for name := range hmacAlgorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// hmacAlgorithmName returns the algorithm, or the default when it isn't set.
+func hmacAlgorithmName(algorithm string) string {
+	if algorithm == "" {
+		return defaultHmacAlgorithm
+	}
+	return algorithm
+}
+
+// hmacCommand gives a shell command which calculates the same id as the algorithm, to help users generate their ids.
+func hmacCommand(algorithm string) string {
+	if hmacAlgorithmName(algorithm) == "blake2b-256" {
+		// openssl's dgst only supports blake2b with a 512 bit digest
+		return "`python3 -c 'import hashlib,hmac,os,sys; print(hmac.new(os.environb[b\"TF_FILE_HMAC_SECRET_KEY\"], open(sys.argv[1], \"rb\").read(), " +
+			"lambda: hashlib.blake2b(digest_size=32)).hexdigest())' \"$FILE_PATH\"`"
+	}
+	return fmt.Sprintf("`openssl dgst -%s -hmac \"$TF_FILE_HMAC_SECRET_KEY\" \"$FILE_PATH\" | awk '{print $2}'`", hmacAlgorithmName(algorithm))
+}
+
+// newBlake2b256 returns an unkeyed BLAKE2b-256 hash, the key is given to the HMAC rather than to BLAKE2b.
+func newBlake2b256() hash.Hash {
+	h, _ := blake2b.New256(nil) // only errors when the key is too long
+	return h
+}
+
+func validateProtected(protected bool, id string, hmacSecretKey string, algorithm string, contents io.Reader) error {
 	if !protected && id != "" {
 		return fmt.Errorf("protected is false, but an id was provided. Either set 'protected' to 'true', or remove 'id' from configuration")
 	}
@@ -435,16 +2500,17 @@ func validateProtected(protected bool, id string, hmacSecretKey string, contents
 	}
 	// if 'protected' is true, then we have an hmac secret 'key' and the user provided an 'id'
 	if protected {
-		calculatedID, err := calculateID(contents, key)
+		calculatedID, err := calculateReaderID(contents, key, algorithm)
 		if err != nil {
 			return fmt.Errorf("problem calculating id from configuration: %s", err.Error())
 		}
 		if id != calculatedID {
 			return fmt.Errorf(
-				"protected is true and a key and id were provided, but the id provided doesn't match our calculations. " +
-					"Please try recalculating your id using a sha256 bit algorithm with the hmac secret key you provided. " +
-					"Here is a bash line that should be equivalent: `openssl dgst -sha256 -hmac \"$TF_FILE_HMAC_SECRET_KEY\" \"$FILE_PATH\" | awk '{print $2}'`. " +
+				"protected is true and a key and id were provided, but the id provided doesn't match our calculations. "+
+					"Please try recalculating your id using the %s algorithm with the hmac secret key you provided. "+
+					"Here is a bash line that should be equivalent: %s. "+
 					"Please make sure your `TF_FILE_HMAC_SECRET_KEY` environment variable is correct if that is how you configured the key",
+				hmacAlgorithmName(algorithm), hmacCommand(algorithm),
 			)
 		} // at this point we have an id, key, contents, protected is true, and our calculated id matches what was provided
 	}
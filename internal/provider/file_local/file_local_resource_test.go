@@ -3,16 +3,37 @@
 package file_local
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"filippo.io/age"
 	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
 	c "github.com/rancher/terraform-provider-file/internal/provider/file_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/lock"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
 )
 
 const (
@@ -23,7 +44,8 @@ const (
 	defaultHmacSecretKey = ""
 )
 
-var booleanFields = []string{"protected", "fake"}
+var booleanFields = []string{"protected", "store_contents", "create_directories", "create_parent_directories", "preserve_mtime_if_unchanged", "exists", "fail_if_missing", "fake"}
+var numberFields = []string{"contents_wo_version"}
 
 func TestLocalResourceMetadata(t *testing.T) {
 	t.Run("Metadata function", func(t *testing.T) {
@@ -125,6 +147,58 @@ func TestLocalResourceCreate(t *testing.T) {
 					"hmac_secret_key": "this-is-a-test-key",
 				}),
 			},
+			{
+				"Protected sha512",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              "e79a4e78743a0c03187fb3da211d5537a113a7af675ec590e13e403f8baf811d32c8c587ecc75edd11306f2514c7ea9c565723d93cebff49e440154902383c0a",
+					"name":            "test_protected_sha512.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha512",
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "e79a4e78743a0c03187fb3da211d5537a113a7af675ec590e13e403f8baf811d32c8c587ecc75edd11306f2514c7ea9c565723d93cebff49e440154902383c0a",
+					"name":            "test_protected_sha512.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha512",
+				}),
+			},
+			{
+				"Protected blake2b-256",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              "ee1e92ec9d89a83f385a22c932275486192704be77778b22ed0fe47cec56b3ef",
+					"name":            "test_protected_blake2b.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "blake2b-256",
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "ee1e92ec9d89a83f385a22c932275486192704be77778b22ed0fe47cec56b3ef",
+					"name":            "test_protected_blake2b.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "blake2b-256",
+				}),
+			},
 			{
 				"Protected using key from environment",
 				LocalResource{client: &c.MemoryFileClient{}},
@@ -149,6 +223,215 @@ func TestLocalResourceCreate(t *testing.T) {
 					"hmac_secret_key": defaultHmacSecretKey,
 				}),
 			},
+			{
+				"Binary contents",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              defaultID,
+					"name":            "test_binary.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_base64": "AAEC//4=", // printf '\x00\x01\x02\xff\xfe' | base64
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "6af26c370aa28039344b9b153d9d4e687f1d346777228c971c4ce1ca56e765b2",
+					"name":            "test_binary.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_base64": "AAEC//4=",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+			},
+			{
+				"Protected using key from provider",
+				LocalResource{
+					client:       &c.MemoryFileClient{},
+					providerData: &provider_data.ProviderData{HmacSecretKey: "this-is-a-test-key"},
+				},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              "4ccd8ec7ea24e0524c8aba459fbf3a2649ec3cd96a1c8f9dfb326cc57a9d3127",
+					"name":            "test_protected.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": defaultHmacSecretKey, // the provider key takes precedence over the environment
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "4ccd8ec7ea24e0524c8aba459fbf3a2649ec3cd96a1c8f9dfb326cc57a9d3127",
+					"name":            "test_protected.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a test",
+					"protected":       "true",
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+			},
+			{
+				"From source",
+				LocalResource{client: sourceClient(t, "/tmp/source/artifact.bin", "this is a source test")},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              defaultID,
+					"name":            "test_source.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"source":          "/tmp/source/artifact.bin",
+					"source_sha256":   "", // unknown
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "1685572ca07d890266a5bc7a6347e0eb838b7a69123debed3d801287b874ccb3",
+					"name":            "test_source.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"source":          "/tmp/source/artifact.bin",
+					"source_sha256":   "2d789add166484b8bc8987f50308431d59dd437bdd65b2e1ddc66def9049469d",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+			},
+			{
+				"Hash only",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have, the contents are only in the config
+				func() resource.CreateRequest {
+					req := getCreateRequest(t, map[string]string{
+						"id":                  defaultID,
+						"name":                "test_hash_only.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"store_contents":      "false",
+						"contents_sha256":     "", // unknown
+						"protected":           defaultProtected,
+						"hmac_secret_key":     defaultHmacSecretKey,
+					})
+					req.Config = getConfig(t, map[string]string{
+						"name":                "test_hash_only.tmp",
+						"contents_wo":         "this is a hash only test",
+						"contents_wo_version": "1",
+						"store_contents":      "false",
+					})
+					return req
+				}(),
+				// want, the state only holds the hash of the contents
+				getCreateResponse(t, map[string]string{
+					"id":                  "c98302c03aea8b68c63753d8370af129d502ec6a19439e6b23a18d857c8173e2",
+					"name":                "test_hash_only.tmp",
+					"directory":           defaultDirectory,
+					"permissions":         defaultPerm,
+					"contents_wo_version": "1",
+					"store_contents":      "false",
+					"contents_sha256":     "5c9a684707ba4e3b6dac2a9bf312435cf93f8b24cc3bd1b6043dba7a4e4bb4c2",
+					"protected":           defaultProtected,
+					"hmac_secret_key":     defaultHmacSecretKey,
+				}),
+			},
+			{
+				"Protected write-only",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have, the write-only contents are only in the config
+				func() resource.CreateRequest {
+					req := getCreateRequest(t, map[string]string{
+						"id":                  "4c2b7fa340eb8b9903122447f471acbab28f9149f8264e7f8a158ea4f5b9e021",
+						"name":                "test_write_only.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"contents_sha256":     "", // unknown
+						"protected":           "true",
+						"hmac_secret_key":     "this-is-a-test-key",
+					})
+					req.Config = getConfig(t, map[string]string{
+						"id":                  "4c2b7fa340eb8b9903122447f471acbab28f9149f8264e7f8a158ea4f5b9e021",
+						"name":                "test_write_only.tmp",
+						"contents_wo":         "this is a write only test",
+						"contents_wo_version": "1",
+						"protected":           "true",
+						"hmac_secret_key":     "this-is-a-test-key",
+					})
+					return req
+				}(),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":                  "4c2b7fa340eb8b9903122447f471acbab28f9149f8264e7f8a158ea4f5b9e021",
+					"name":                "test_write_only.tmp",
+					"directory":           defaultDirectory,
+					"permissions":         defaultPerm,
+					"contents_wo_version": "1",
+					"contents_sha256":     "e8402cb75ce3d6d7b22f6ba1d18ce3f992b4e8b95e180f6afe6d4e7401d28dc6",
+					"protected":           "true",
+					"hmac_secret_key":     "this-is-a-test-key",
+				}),
+			},
+			{
+				"Owner and group",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":              defaultID,
+					"name":            "test_owner.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"owner":           "1000",
+					"group":           "1000",
+					"contents":        "this is a basic test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"id":              "3de642fb91d2fb0ce02fe66c3d19ebdf44cbc6a2ebcc2dad22f1950b67c1217f",
+					"name":            "test_owner.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"owner":           "1000",
+					"group":           "1000",
+					"contents":        "this is a basic test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+			},
+			{
+				"Parent directories",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"id":                        defaultID,
+					"name":                      "test_parents.tmp",
+					"directory":                 "/tmp/parents",
+					"permissions":               defaultPerm,
+					"contents":                  "this is a basic test",
+					"protected":                 defaultProtected,
+					"hmac_secret_key":           defaultHmacSecretKey,
+					"create_parent_directories": "true",
+				}),
+				// want
+				func() resource.CreateResponse {
+					resp := getCreateResponse(t, map[string]string{
+						"id":                        "3de642fb91d2fb0ce02fe66c3d19ebdf44cbc6a2ebcc2dad22f1950b67c1217f",
+						"name":                      "test_parents.tmp",
+						"directory":                 "/tmp/parents",
+						"permissions":               defaultPerm,
+						"contents":                  "this is a basic test",
+						"protected":                 defaultProtected,
+						"hmac_secret_key":           defaultHmacSecretKey,
+						"create_parent_directories": "true",
+					})
+					resp.State.SetAttribute(context.Background(), path.Root("created_directories"), []string{"/tmp/parents"})
+					return resp
+				}(),
+			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
@@ -211,6 +494,7 @@ func TestLocalResourceRead(t *testing.T) {
 					"contents":        "this is an unprotected read test",
 					"protected":       defaultProtected,
 					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
 				}),
 				map[string]string{
 					"mode":      defaultPerm,
@@ -241,6 +525,7 @@ func TestLocalResourceRead(t *testing.T) {
 					"contents":        "this is a protected read test",
 					"protected":       "true",
 					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
 				}),
 				// reality
 				map[string]string{
@@ -272,203 +557,2191 @@ func TestLocalResourceRead(t *testing.T) {
 					"contents":        "this is a change in contents in the real file",
 					"protected":       "true",
 					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_protected_content.tmp",
+					"contents":  "this is a change in contents in the real file",
+				},
+			},
+			{
+				"Binary contents with content update",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":              "6af26c370aa28039344b9b153d9d4e687f1d346777228c971c4ce1ca56e765b2",
+					"name":            "read_binary.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_base64": "AAEC//4=",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getReadResponse(t, map[string]string{
+					"id":              "897871ed26a340a5edef5e0e0f3474e81e124a71b06387b048d9e4d7c8a6c169",
+					"name":            "read_binary.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_base64": "H4sIAP8=",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_binary.tmp",
+					"contents":  "\x1f\x8b\x08\x00\xff",
+				},
+			},
+			{
+				"Protected with mode update",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":              "ec4407ba53b2c40ac2ac18ff7372a6fe6e4f7f8aa04f340503aefc7d9a5fa4e1",
+					"name":            "read_protected_mode.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a protected read test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+				}),
+				// want
+				getReadResponse(t, map[string]string{
+					"id":              "ec4407ba53b2c40ac2ac18ff7372a6fe6e4f7f8aa04f340503aefc7d9a5fa4e1",
+					"name":            "read_protected_mode.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     "0755",
+					"contents":        "this is a protected read test",
+					"protected":       "true",
+					"hmac_secret_key": "this-is-a-test-key",
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      "0755",
+					"directory": defaultDirectory,
+					"name":      "read_protected_mode.tmp",
+					"contents":  "this is a protected read test",
+				},
+			},
+			{
+				"Source with changed file",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":              "1685572ca07d890266a5bc7a6347e0eb838b7a69123debed3d801287b874ccb3",
+					"name":            "read_source.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"source":          "/tmp/source/artifact.bin",
+					"source_sha256":   "2d789add166484b8bc8987f50308431d59dd437bdd65b2e1ddc66def9049469d",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getReadResponse(t, map[string]string{
+					"id":              "e8b3c1e131d4e36f9146451b9368495919949765865a5617f1174db3c4e1f80e",
+					"name":            "read_source.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"source":          "/tmp/source/artifact.bin",
+					"source_sha256":   "d7ce2580f9e1fe6f7e382b7ac916ac638ada52f8fd770fcb9a85981583058443",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_source.tmp",
+					"contents":  "this is a changed source test",
+				},
+			},
+			{
+				"Hash only with changed file",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":                  "c98302c03aea8b68c63753d8370af129d502ec6a19439e6b23a18d857c8173e2",
+					"name":                "read_hash_only.tmp",
+					"directory":           defaultDirectory,
+					"permissions":         defaultPerm,
+					"contents_wo_version": "1",
+					"store_contents":      "false",
+					"contents_sha256":     "5c9a684707ba4e3b6dac2a9bf312435cf93f8b24cc3bd1b6043dba7a4e4bb4c2",
+					"protected":           defaultProtected,
+					"hmac_secret_key":     defaultHmacSecretKey,
+				}),
+				// want, the contents on disk never make it into the state
+				getReadResponse(t, map[string]string{
+					"id":                  "f9f37bc5b42aaafe84b78cee786b126f928bbc5541d2a41382461b13282501d9",
+					"name":                "read_hash_only.tmp",
+					"directory":           defaultDirectory,
+					"permissions":         defaultPerm,
+					"contents_wo_version": "1",
+					"store_contents":      "false",
+					"contents_sha256":     "13cd8aba3c65b9592f72289a5a2f293730dde1b645638e351cfccef6f375749f",
+					"protected":           defaultProtected,
+					"hmac_secret_key":     defaultHmacSecretKey,
+					"hmac_algorithm":      "sha256",
 				}),
 				// reality
 				map[string]string{
-					"mode":      defaultPerm,
-					"directory": defaultDirectory,
-					"name":      "read_protected_content.tmp",
-					"contents":  "this is a change in contents in the real file",
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "read_hash_only.tmp",
+					"contents":  "this is a changed hash only test",
+				},
+			},
+			{
+				"Owner changed",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":              "60cef95046105ff4522c0c1f1aeeeba43d0d729dbcabdd8846c317c98cac60a2",
+					"name":            "read_owner.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"owner":           "1000",
+					"group":           "1000",
+					"contents":        "this is an unprotected read test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getReadResponse(t, map[string]string{
+					"id":              "60cef95046105ff4522c0c1f1aeeeba43d0d729dbcabdd8846c317c98cac60a2",
+					"name":            "read_owner.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"owner":           "1001",
+					"group":           "1000",
+					"contents":        "this is an unprotected read test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+					"hmac_algorithm":  "sha256",
+				}),
+				// reality
+				map[string]string{
+					"mode":      defaultPerm,
+					"owner":     "1001",
+					"group":     "1000",
+					"directory": defaultDirectory,
+					"name":      "read_owner.tmp",
+					"contents":  "this is an unprotected read test",
+				},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				if err := tc.fit.client.Chown(tc.setup["directory"], tc.setup["name"], tc.setup["owner"], tc.setup["group"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				defer func() {
+					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
+						t.Errorf("Error tearing down: %v", err)
+					}
+				}()
+				r := getReadResponseContainer()
+				tc.fit.Read(context.Background(), tc.have, &r)
+				got := r
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
+func TestLocalResourceUpdate(t *testing.T) {
+	t.Run("Update function", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			fit   LocalResource
+			have  resource.UpdateRequest
+			want  resource.UpdateResponse
+			setup map[string]string
+		}{
+			{
+				"Basic test",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getUpdateRequest(t, map[string]map[string]string{
+					"priorState": {
+						"id":              defaultID,
+						"name":            "update_basic.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is an update test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "update_basic.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a basic update test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":              "0ec41eee6c157a3f7e50b78d586ee2ddb4d6e93b6de8bdf6d9354cf720e89549",
+					"name":            "update_basic.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a basic update test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_basic.tmp",
+					"contents":  "this is an update test",
+				},
+			},
+			{
+				"Contents to source",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getUpdateRequest(t, map[string]map[string]string{
+					"priorState": {
+						"id":              defaultID,
+						"name":            "update_source.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is an update test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "update_source.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"source":          "/tmp/source/artifact.bin",
+						"source_sha256":   "2d789add166484b8bc8987f50308431d59dd437bdd65b2e1ddc66def9049469d",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":              "1685572ca07d890266a5bc7a6347e0eb838b7a69123debed3d801287b874ccb3",
+					"name":            "update_source.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"source":          "/tmp/source/artifact.bin",
+					"source_sha256":   "2d789add166484b8bc8987f50308431d59dd437bdd65b2e1ddc66def9049469d",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// setup, the memory client only holds one file so the source stands in for the existing file
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": "/tmp/source",
+					"name":      "artifact.bin",
+					"contents":  "this is a source test",
+				},
+			},
+			{
+				"Rotate key",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getUpdateRequest(t, map[string]map[string]string{
+					"priorState": {
+						"id":              "4b767bbef0fa7eb63ec2d76a87447cb733a70bb50056f0373afa575e7122c6e1",
+						"name":            "update_rotate.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a rotation test",
+						"protected":       "true",
+						"hmac_secret_key": "this-is-a-test-key",
+					},
+					"plan": {
+						"id":                       "da803dd64908e2ed22a1a6181c8c5567326df7a41c700b03ab016884b51731ff",
+						"name":                     "update_rotate.tmp",
+						"directory":                defaultDirectory,
+						"permissions":              defaultPerm,
+						"contents":                 "this is a rotation test",
+						"protected":                "true",
+						"hmac_secret_key":          "this-is-a-new-test-key",
+						"previous_hmac_secret_key": "this-is-a-test-key",
+					},
+				}),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":                       "da803dd64908e2ed22a1a6181c8c5567326df7a41c700b03ab016884b51731ff",
+					"name":                     "update_rotate.tmp",
+					"directory":                defaultDirectory,
+					"permissions":              defaultPerm,
+					"contents":                 "this is a rotation test",
+					"protected":                "true",
+					"hmac_secret_key":          "this-is-a-new-test-key",
+					"previous_hmac_secret_key": "this-is-a-test-key",
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_rotate.tmp",
+					"contents":  "this is a rotation test",
+				},
+			},
+			{
+				"Protected write-only version change",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have, the previous id is validated against the file on disk
+				func() resource.UpdateRequest {
+					req := getUpdateRequest(t, map[string]map[string]string{
+						"priorState": {
+							"id":                  "4c2b7fa340eb8b9903122447f471acbab28f9149f8264e7f8a158ea4f5b9e021",
+							"name":                "update_write_only.tmp",
+							"directory":           defaultDirectory,
+							"permissions":         defaultPerm,
+							"contents_wo_version": "1",
+							"contents_sha256":     "e8402cb75ce3d6d7b22f6ba1d18ce3f992b4e8b95e180f6afe6d4e7401d28dc6",
+							"protected":           "true",
+							"hmac_secret_key":     "this-is-a-test-key",
+						},
+						"plan": {
+							"id":                  "c69b6e203b87885ae62b548c6163fbe0a68e26a14603987b2480e503ef7df5e1",
+							"name":                "update_write_only.tmp",
+							"directory":           defaultDirectory,
+							"permissions":         defaultPerm,
+							"contents_wo_version": "2",
+							"contents_sha256":     "ed2a3df8c829f70d1257a918dc1f09c3a63e39ffe90661c4269d21ad8b8e68c8",
+							"protected":           "true",
+							"hmac_secret_key":     "this-is-a-test-key",
+						},
+					})
+					req.Config = getConfig(t, map[string]string{
+						"id":                  "c69b6e203b87885ae62b548c6163fbe0a68e26a14603987b2480e503ef7df5e1",
+						"name":                "update_write_only.tmp",
+						"contents_wo":         "this is a changed write only test",
+						"contents_wo_version": "2",
+						"protected":           "true",
+						"hmac_secret_key":     "this-is-a-test-key",
+					})
+					return req
+				}(),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":                  "c69b6e203b87885ae62b548c6163fbe0a68e26a14603987b2480e503ef7df5e1",
+					"name":                "update_write_only.tmp",
+					"directory":           defaultDirectory,
+					"permissions":         defaultPerm,
+					"contents_wo_version": "2",
+					"contents_sha256":     "ed2a3df8c829f70d1257a918dc1f09c3a63e39ffe90661c4269d21ad8b8e68c8",
+					"protected":           "true",
+					"hmac_secret_key":     "this-is-a-test-key",
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_write_only.tmp",
+					"contents":  "this is a write only test",
+				},
+			},
+			{
+				"Backup",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				func() resource.UpdateRequest {
+					req := getUpdateRequest(t, map[string]map[string]string{
+						"priorState": {
+							"id":              defaultID,
+							"name":            "update_backup.tmp",
+							"directory":       defaultDirectory,
+							"permissions":     defaultPerm,
+							"contents":        "this is an update test",
+							"protected":       defaultProtected,
+							"hmac_secret_key": defaultHmacSecretKey,
+						},
+						"plan": {
+							"id":              defaultID,
+							"name":            "update_backup.tmp",
+							"directory":       defaultDirectory,
+							"permissions":     defaultPerm,
+							"contents":        "this is a backup update test",
+							"protected":       defaultProtected,
+							"hmac_secret_key": defaultHmacSecretKey,
+						},
+					})
+					backup := &LocalBackupModel{Count: types.Int64Value(2), SuffixFormat: types.StringNull()}
+					req.State.SetAttribute(context.Background(), path.Root("backup"), backup)
+					req.State.SetAttribute(context.Background(), path.Root("backups"), []string{})
+					req.Plan.SetAttribute(context.Background(), path.Root("backup"), backup)
+					return req
+				}(),
+				// want
+				func() resource.UpdateResponse {
+					resp := getUpdateResponse(t, map[string]string{
+						"id":              "0cd951edcab6392fb8a162e41892f04e2704fac5bf53723ff36f0c7e6fc8156d",
+						"name":            "update_backup.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a backup update test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					})
+					resp.State.SetAttribute(context.Background(), path.Root("backup"), &LocalBackupModel{Count: types.Int64Value(2), SuffixFormat: types.StringNull()})
+					resp.State.SetAttribute(context.Background(), path.Root("backups"), []string{"update_backup.tmp.1"})
+					return resp
+				}(),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_backup.tmp",
+					"contents":  "this is an update test",
+				},
+			},
+			{
+				"Modified time",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getUpdateRequest(t, map[string]map[string]string{
+					"priorState": {
+						"id":              "d6b8ccd6f3107de4028abe24412485186960b865120358df2467a80e5c00bac3",
+						"name":            "update_times.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a times test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+						"modified_time":   "2025-01-02T15:04:05Z",
+					},
+					"plan": {
+						"id":              "d6b8ccd6f3107de4028abe24412485186960b865120358df2467a80e5c00bac3",
+						"name":            "update_times.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a times test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+						"modified_time":   "2000-01-01T00:00:00Z",
+					},
+				}),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":              "d6b8ccd6f3107de4028abe24412485186960b865120358df2467a80e5c00bac3",
+					"name":            "update_times.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a times test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+					"modified_time":   "2000-01-01T00:00:00Z",
+				}),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_times.tmp",
+					"contents":  "this is a times test",
+				},
+			},
+			{
+				"Extended attributes",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				func() resource.UpdateRequest {
+					req := getUpdateRequest(t, map[string]map[string]string{
+						"priorState": {
+							"id":              "1246785f229df953cd66748998baca030cfa0c62b086d03bd19f81bc4ea04ba4",
+							"name":            "update_xattrs.tmp",
+							"directory":       defaultDirectory,
+							"permissions":     defaultPerm,
+							"contents":        "this is an xattrs test",
+							"protected":       defaultProtected,
+							"hmac_secret_key": defaultHmacSecretKey,
+						},
+						"plan": {
+							"id":              "1246785f229df953cd66748998baca030cfa0c62b086d03bd19f81bc4ea04ba4",
+							"name":            "update_xattrs.tmp",
+							"directory":       defaultDirectory,
+							"permissions":     defaultPerm,
+							"contents":        "this is an xattrs test",
+							"protected":       defaultProtected,
+							"hmac_secret_key": defaultHmacSecretKey,
+							"selinux_context": "system_u:object_r:etc_t:s0",
+						},
+					})
+					req.State.SetAttribute(context.Background(), path.Root("xattrs"), map[string]string{"user.owner": "ops", "user.team": "web"})
+					req.Plan.SetAttribute(context.Background(), path.Root("xattrs"), map[string]string{"user.owner": "dev"})
+					return req
+				}(),
+				// want
+				func() resource.UpdateResponse {
+					resp := getUpdateResponse(t, map[string]string{
+						"id":              "1246785f229df953cd66748998baca030cfa0c62b086d03bd19f81bc4ea04ba4",
+						"name":            "update_xattrs.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is an xattrs test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+						"selinux_context": "system_u:object_r:etc_t:s0",
+					})
+					resp.State.SetAttribute(context.Background(), path.Root("xattrs"), map[string]string{"user.owner": "dev"})
+					return resp
+				}(),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "update_xattrs.tmp",
+					"contents":  "this is an xattrs test",
+				},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				defer func() {
+					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
+						t.Errorf("Error tearing down: %v", err)
+					}
+				}()
+				r := getUpdateResponseContainer()
+				tc.fit.Update(context.Background(), tc.have, &r)
+				got := r
+				var plannedState LocalResourceModel
+				if diags := tc.have.Plan.Get(context.Background(), &plannedState); diags.HasError() {
+					t.Errorf("Failed to get planned state: %v", diags)
+				}
+				plannedContents := plannedState.Contents.ValueString()
+				if !plannedState.Source.IsNull() {
+					plannedContents = tc.setup["contents"]
+				}
+				if writeOnly(plannedState) {
+					var contentsWo types.String
+					if diags := tc.have.Config.GetAttribute(context.Background(), path.Root("contents_wo"), &contentsWo); diags.HasError() {
+						t.Errorf("Failed to get write-only contents: %v", diags)
+					}
+					plannedContents = contentsWo.ValueString()
+				}
+				_, contentsAfterUpdate, err := tc.fit.client.Read(plannedState.Directory.ValueString(), plannedState.Name.ValueString())
+				if err != nil {
+					t.Errorf("Failed to read file for update verification: %s", err)
+				}
+				if contentsAfterUpdate != plannedContents {
+					t.Errorf("File content was not updated correctly. Got %q, want %q", contentsAfterUpdate, plannedContents)
+				}
+				if !plannedState.ModifiedTime.IsNull() {
+					info, err := tc.fit.client.Info(plannedState.Directory.ValueString(), plannedState.Name.ValueString())
+					if err != nil {
+						t.Errorf("Failed to stat file for update verification: %s", err)
+					}
+					if info["ModifiedTime"] != plannedState.ModifiedTime.ValueString() {
+						t.Errorf("File time was not updated correctly. Got %q, want %q", info["ModifiedTime"], plannedState.ModifiedTime.ValueString())
+					}
+				}
+				if !plannedState.Xattrs.IsNull() {
+					xattrs, err := tc.fit.client.Xattrs(plannedState.Directory.ValueString(), plannedState.Name.ValueString())
+					if err != nil {
+						t.Errorf("Failed to get extended attributes for update verification: %s", err)
+					}
+					want := map[string]string{}
+					plannedState.Xattrs.ElementsAs(context.Background(), &want, false)
+					want["security.selinux"] = plannedState.SelinuxContext.ValueString()
+					if diff := cmp.Diff(want, xattrs); diff != "" {
+						t.Errorf("Extended attributes were not updated correctly (-want +got):\n%s", diff)
+					}
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("Update() mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
+
+func TestLocalResourceDelete(t *testing.T) {
+	t.Run("Delete function", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			fit   LocalResource
+			have  resource.DeleteRequest
+			want  resource.DeleteResponse
+			setup map[string]string
+		}{
+			{
+				"Basic test",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getDeleteRequest(t, map[string]string{
+					"id":              "fd6fb8621c4850c228190f4d448ce30881a32609d6b4c7341d48d0027e597567",
+					"name":            "delete.tmp",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents":        "this is a delete test",
+					"protected":       defaultProtected,
+					"hmac_secret_key": defaultHmacSecretKey,
+				}),
+				// want
+				getDeleteResponse(),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "delete.tmp",
+					"contents":  "this is a delete test",
+				},
+			},
+			{
+				"Retain",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getDeleteRequest(t, map[string]string{
+					"id":               "fd6fb8621c4850c228190f4d448ce30881a32609d6b4c7341d48d0027e597567",
+					"name":             "delete.tmp",
+					"directory":        defaultDirectory,
+					"permissions":      defaultPerm,
+					"contents":         "this is a delete test",
+					"protected":        defaultProtected,
+					"hmac_secret_key":  defaultHmacSecretKey,
+					"destroy_behavior": "retain",
+				}),
+				// want
+				getDeleteResponse(),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "delete.tmp",
+					"contents":  "this is a delete test",
+					"retained":  "true",
+				},
+			},
+			{
+				"Restore without an original",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getDeleteRequest(t, map[string]string{
+					"id":               "fd6fb8621c4850c228190f4d448ce30881a32609d6b4c7341d48d0027e597567",
+					"name":             "delete.tmp",
+					"directory":        defaultDirectory,
+					"permissions":      defaultPerm,
+					"contents":         "this is a delete test",
+					"protected":        defaultProtected,
+					"hmac_secret_key":  defaultHmacSecretKey,
+					"destroy_behavior": "restore",
+				}),
+				// want, there wasn't a file before so it is deleted
+				getDeleteResponse(),
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": defaultDirectory,
+					"name":      "delete.tmp",
+					"contents":  "this is a delete test",
+				},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				r := getDeleteResponseContainer()
+				tc.fit.Delete(context.Background(), tc.have, &r)
+				got := r
+				if tc.setup["retained"] == "true" {
+					if _, _, err := tc.fit.client.Read(tc.setup["directory"], tc.setup["name"]); err != nil {
+						t.Errorf("Expected file to be retained, but reading it failed: %v", err)
+					}
+					if diff := cmp.Diff(tc.want, got); diff != "" {
+						t.Errorf("Delete() mismatch (-want +got):\n%s", diff)
+					}
+					return
+				}
+				// Verify the file was actually deleted from disk
+				if _, c, err := tc.fit.client.Read(tc.setup["directory"], tc.setup["name"]); err == nil || err.Error() != "file not found" {
+					if err == nil {
+						t.Errorf("Expected file to be delete, but it still exists. File contents: %s", c)
+					}
+					t.Errorf("Expected file to be deleted, but it still exists. Error: %s", err.Error())
+				}
+				// verify that the file was removed from state
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("Update() mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
+
+func TestLocalResourceImportState(t *testing.T) {
+	t.Run("ImportState function", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			fit   LocalResource
+			have  resource.ImportStateRequest
+			want  resource.ImportStateResponse
+			setup map[string]string
+		}{
+			{
+				"Path",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				resource.ImportStateRequest{ID: "/tmp/import/import.tmp"},
+				// want
+				resource.ImportStateResponse{
+					State: getReadResponse(t, map[string]string{
+						"id":                          "3a7e3e194a8675123f181d2b95acc32bc7ceb74dce9668c236150bdb55df4006",
+						"name":                        "import.tmp",
+						"directory":                   "/tmp/import",
+						"permissions":                 defaultPerm,
+						"contents":                    "this is an import test",
+						"store_contents":              "true",
+						"protected":                   defaultProtected,
+						"hmac_secret_key":             defaultHmacSecretKey,
+						"hmac_algorithm":              "sha256",
+						"destroy_behavior":            "delete",
+						"if_exists":                   "overwrite",
+						"create_parent_directories":   "false",
+						"preserve_mtime_if_unchanged": "false",
+						"line_endings":                "preserve",
+						"encoding":                    "utf-8",
+					}).State,
+				},
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": "/tmp/import",
+					"name":      "import.tmp",
+					"contents":  "this is an import test",
+				},
+			},
+			{
+				"Protected path",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				resource.ImportStateRequest{ID: "/tmp/import/import_protected.tmp,protected"},
+				// want
+				resource.ImportStateResponse{
+					State: getReadResponse(t, map[string]string{
+						"id":                          "b376078e3531e5698081ad2b86e950650cce4417e2cbe5266b4830e722f77e40",
+						"name":                        "import_protected.tmp",
+						"directory":                   "/tmp/import",
+						"permissions":                 defaultPerm,
+						"contents":                    "this is an import test",
+						"store_contents":              "true",
+						"protected":                   "true",
+						"hmac_secret_key":             defaultHmacSecretKey,
+						"hmac_algorithm":              "sha256",
+						"destroy_behavior":            "delete",
+						"if_exists":                   "overwrite",
+						"create_parent_directories":   "false",
+						"preserve_mtime_if_unchanged": "false",
+						"line_endings":                "preserve",
+						"encoding":                    "utf-8",
+					}).State,
+				},
+				// setup
+				map[string]string{
+					"mode":      defaultPerm,
+					"directory": "/tmp/import",
+					"name":      "import_protected.tmp",
+					"contents":  "this is an import test",
+				},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Setenv("TF_FILE_HMAC_SECRET_KEY", "test-hmac-secret-key-123")
+				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+				}
+				defer func() {
+					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
+						t.Errorf("Error tearing down: %v", err)
+					}
+				}()
+				r := resource.ImportStateResponse{
+					State: tfsdk.State{
+						Raw:    tftypes.NewValue(getObjectAttributeTypes(), nil),
+						Schema: getLocalResourceSchema().Schema,
+					},
+				}
+				tc.fit.ImportState(context.Background(), tc.have, &r)
+				got := r
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("ImportState() mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
+
+func TestDriftedAttributes(t *testing.T) {
+	state := LocalResourceModel{
+		Contents:    types.StringValue("this is a drift test"),
+		Permissions: types.StringValue(defaultPerm),
+		Owner:       types.StringValue("1000"),
+		Xattrs:      types.MapValueMust(types.StringType, map[string]attr.Value{"user.owner": types.StringValue("ops")}),
+	}
+	testCases := []struct {
+		name    string
+		reality LocalResourceModel
+		want    []string
+	}{
+		{"No drift", state, []string{}},
+		{
+			"Contents and permissions",
+			LocalResourceModel{
+				Contents:    types.StringValue("this was edited by hand"),
+				Permissions: types.StringValue("0644"),
+				Owner:       types.StringValue("1000"),
+				Xattrs:      state.Xattrs,
+			},
+			[]string{"contents", "permissions"},
+		},
+		{
+			"Owner",
+			LocalResourceModel{
+				Contents:    types.StringValue("this is a drift test"),
+				Permissions: types.StringValue(defaultPerm),
+				Owner:       types.StringValue("1001"),
+				Xattrs:      state.Xattrs,
+			},
+			[]string{"owner"},
+		},
+		{
+			"Extended attributes",
+			LocalResourceModel{
+				Contents:    types.StringValue("this is a drift test"),
+				Permissions: types.StringValue(defaultPerm),
+				Owner:       types.StringValue("1000"),
+				Xattrs:      types.MapValueMust(types.StringType, map[string]attr.Value{}),
+			},
+			[]string{"xattrs"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, driftedAttributes(state, tc.reality)); diff != "" {
+				t.Errorf("driftedAttributes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	testCases := []struct {
+		name    string
+		current types.String
+		actual  string
+		want    types.String
+	}{
+		{"Unmanaged", types.StringNull(), "2025-01-02T15:04:05Z", types.StringNull()},
+		{"Same time", types.StringValue("2025-01-02T15:04:05Z"), "2025-01-02T15:04:05Z", types.StringValue("2025-01-02T15:04:05Z")},
+		{"Other zone", types.StringValue("2025-01-02T16:04:05+01:00"), "2025-01-02T15:04:05.5Z", types.StringValue("2025-01-02T16:04:05+01:00")},
+		{"Changed", types.StringValue("2025-01-02T15:04:05Z"), "2025-03-04T05:06:07.123Z", types.StringValue("2025-03-04T05:06:07.123Z")},
+		{"Unknown to the client", types.StringValue("2025-01-02T15:04:05Z"), "", types.StringValue("2025-01-02T15:04:05Z")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, timeValue(tc.current, tc.actual)); diff != "" {
+				t.Errorf("timeValue() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLocalResourceLock(t *testing.T) {
+	testCases := []struct {
+		name      string
+		heldBy    string // the path another holder has locked
+		wantError string
+	}{
+		{"Unlocked", "", ""},
+		{"Held", filepath.Join(defaultDirectory, "update_lock.tmp"), "held by another memory client lock"},
+		{"Other file", filepath.Join(defaultDirectory, "other.tmp"), ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &c.MemoryFileClient{}
+			fit := LocalResource{client: client}
+			if err := client.Create(defaultDirectory, "update_lock.tmp", "this is a lock test", defaultPerm); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			if tc.heldBy != "" {
+				if _, err := client.Lock(filepath.Dir(tc.heldBy), filepath.Base(tc.heldBy), "lockfile", 0); err != nil {
+					t.Fatalf("Error setting up: %v", err)
+				}
+			}
+			file := map[string]string{
+				"id":              defaultID,
+				"name":            "update_lock.tmp",
+				"directory":       defaultDirectory,
+				"permissions":     defaultPerm,
+				"contents":        "this is a lock test",
+				"protected":       defaultProtected,
+				"hmac_secret_key": defaultHmacSecretKey,
+			}
+			req := getUpdateRequest(t, map[string]map[string]string{"priorState": file, "plan": file})
+			lockBlock := &LocalLockModel{Mode: types.StringValue("lockfile"), Timeout: types.StringValue("0s")}
+			req.State.SetAttribute(context.Background(), path.Root("lock"), lockBlock)
+			req.Plan.SetAttribute(context.Background(), path.Root("lock"), lockBlock)
+			resp := getUpdateResponseContainer()
+			fit.Update(context.Background(), req, &resp)
+			if tc.wantError == "" && resp.Diagnostics.HasError() {
+				t.Fatalf("Update() errors: %v", resp.Diagnostics)
+			}
+			if tc.wantError != "" && (!resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), tc.wantError)) {
+				t.Fatalf("Update() errors are %v; want an error containing %q", resp.Diagnostics, tc.wantError)
+			}
+			// the lock taken by Update is released, another holder's lock is left alone
+			release, err := client.Lock(defaultDirectory, "update_lock.tmp", "lockfile", 0)
+			if (err == nil) != (tc.heldBy != filepath.Join(defaultDirectory, "update_lock.tmp")) {
+				t.Errorf("Lock() after Update() error is %v", err)
+			}
+			if err == nil {
+				_ = release()
+			}
+		})
+	}
+}
+
+// TestLocalResourceLockProcess has another process hold the flock while replacing the file, like the provider does,
+// the provider has to wait for that process to release the lock before it updates the file.
+func TestLocalResourceLockProcess(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		t.Skipf("flock isn't available on %s", runtime.GOOS)
+	}
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "process_lock.tmp"), []byte("this is a lock test"), 0600); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	holder := exec.Command(os.Args[0], "-test.run=^TestLockHolderProcess$")
+	holder.Env = append(os.Environ(), "TF_FILE_TEST_LOCK_HOLDER="+filepath.Join(directory, "process_lock.tmp"))
+	stdout, err := holder.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	if err = holder.Start(); err != nil {
+		t.Fatalf("Error starting the lock holder: %v", err)
+	}
+	defer func() { _ = holder.Wait() }()
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "locked\n" {
+		t.Fatalf("Lock holder printed %q, %v; want it to say it is locked", line, err)
+	}
+
+	fit := LocalResource{client: &c.OsFileClient{}}
+	priorState := map[string]string{
+		"id":              defaultID,
+		"name":            "process_lock.tmp",
+		"directory":       directory,
+		"permissions":     defaultPerm,
+		"contents":        "this is a lock test",
+		"protected":       defaultProtected,
+		"hmac_secret_key": defaultHmacSecretKey,
+	}
+	plan := maps.Clone(priorState)
+	plan["contents"] = "this is an updated lock test"
+	req := getUpdateRequest(t, map[string]map[string]string{"priorState": priorState, "plan": plan})
+	lockBlock := &LocalLockModel{Mode: types.StringValue("flock"), Timeout: types.StringValue("10s")}
+	req.State.SetAttribute(context.Background(), path.Root("lock"), lockBlock)
+	req.Plan.SetAttribute(context.Background(), path.Root("lock"), lockBlock)
+	resp := getUpdateResponseContainer()
+	fit.Update(context.Background(), req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Update() errors: %v", resp.Diagnostics)
+	}
+	if _, err = os.Stat(filepath.Join(directory, "released")); err != nil {
+		t.Errorf("Update() didn't wait for the lock holder to release the lock: %v", err)
+	}
+	contents, err := os.ReadFile(filepath.Join(directory, "process_lock.tmp"))
+	if err != nil || string(contents) != "this is an updated lock test" {
+		t.Errorf("Update() wrote %q, %v; want the updated contents", contents, err)
+	}
+}
+
+// TestLockHolderProcess is the other process for TestLocalResourceLockProcess, it only runs when that test starts it.
+// It locks the file, replaces it, then keeps the lock for a second before recording that it released it.
+func TestLockHolderProcess(t *testing.T) {
+	filePath := os.Getenv("TF_FILE_TEST_LOCK_HOLDER")
+	if filePath == "" {
+		t.Skip("this is started by TestLocalResourceLockProcess")
+	}
+	release, err := lock.Acquire(filePath, lock.Flock, 0)
+	if err != nil {
+		t.Fatalf("Error locking: %v", err)
+	}
+	if err = os.WriteFile(filePath+".new", []byte("this is a replaced lock test"), 0600); err != nil {
+		t.Fatalf("Error writing: %v", err)
+	}
+	if err = os.Rename(filePath+".new", filePath); err != nil {
+		t.Fatalf("Error replacing: %v", err)
+	}
+	fmt.Println("locked")
+	time.Sleep(time.Second)
+	if err = os.WriteFile(filepath.Join(filepath.Dir(filePath), "released"), nil, 0600); err != nil {
+		t.Fatalf("Error writing: %v", err)
+	}
+	if err = release(); err != nil {
+		t.Fatalf("Error releasing: %v", err)
+	}
+}
+
+// TestLocalResourceRestoreOriginal copies the file a resource replaces and puts it back, without the contents in private state.
+func TestLocalResourceRestoreOriginal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the permissions of the copy aren't kept on Windows")
+	}
+	contents := "this is the original file"
+	setup := func(t *testing.T) (LocalResource, string) {
+		directory := t.TempDir()
+		if err := os.WriteFile(filepath.Join(directory, "restore.tmp"), []byte(contents), 0600); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		if err := os.Chmod(filepath.Join(directory, "restore.tmp"), 0640); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		return LocalResource{client: &c.OsFileClient{}}, directory
+	}
+	// the managed file which replaces the original
+	replace := func(t *testing.T, fit LocalResource, directory string) {
+		if err := fit.client.Create(directory, "restore.tmp", "this is a restore test", defaultPerm); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+	}
+
+	t.Run("Restored", func(t *testing.T) {
+		fit, directory := setup(t)
+		original, err := fit.copyOriginal(directory, "restore.tmp")
+		if err != nil {
+			t.Fatalf("copyOriginal() error: %v", err)
+		}
+		value, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("Error saving original: %v", err)
+		}
+		if strings.Contains(string(value), contents) || strings.Contains(string(value), base64.StdEncoding.EncodeToString([]byte(contents))) {
+			t.Errorf("copyOriginal() saved the contents in private state: %s", value)
+		}
+		perm, copied, err := fit.client.Read(directory, "restore.tmp.original")
+		if err != nil || copied != contents || perm != "0640" {
+			t.Fatalf("copyOriginal() copy is %q with mode %s, %v; want %q with mode 0640", copied, perm, err, contents)
+		}
+		replace(t, fit, directory)
+		if err := fit.putBackOriginal(original, directory, "restore.tmp"); err != nil {
+			t.Fatalf("putBackOriginal() error: %v", err)
+		}
+		perm, restored, err := fit.client.Read(directory, "restore.tmp")
+		if err != nil || restored != contents || perm != "0640" {
+			t.Errorf("putBackOriginal() file is %q with mode %s, %v; want %q with mode 0640", restored, perm, err, contents)
+		}
+		if _, err := os.Stat(filepath.Join(directory, "restore.tmp.original")); !os.IsNotExist(err) {
+			t.Errorf("putBackOriginal() left the copy: %v", err)
+		}
+	})
+	t.Run("Changed copy", func(t *testing.T) {
+		fit, directory := setup(t)
+		original, err := fit.copyOriginal(directory, "restore.tmp")
+		if err != nil {
+			t.Fatalf("copyOriginal() error: %v", err)
+		}
+		replace(t, fit, directory)
+		if err := os.WriteFile(filepath.Join(directory, "restore.tmp.original"), []byte("this was changed"), 0640); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		if err := fit.putBackOriginal(original, directory, "restore.tmp"); err == nil || !strings.Contains(err.Error(), "was changed") {
+			t.Errorf("putBackOriginal() error is %v; want the copy was changed", err)
+		}
+		if _, managed, err := fit.client.Read(directory, "restore.tmp"); err != nil || managed != "this is a restore test" {
+			t.Errorf("putBackOriginal() changed the managed file to %q, %v", managed, err)
+		}
+	})
+	t.Run("Existing copy", func(t *testing.T) {
+		fit, directory := setup(t)
+		if err := os.WriteFile(filepath.Join(directory, "restore.tmp.original"), []byte("this is someone else's file"), 0600); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		if _, err := fit.copyOriginal(directory, "restore.tmp"); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("copyOriginal() error is %v; want the copy already exists", err)
+		}
+		if _, other, _ := fit.client.Read(directory, "restore.tmp.original"); other != "this is someone else's file" {
+			t.Errorf("copyOriginal() overwrote an existing file with %q", other)
+		}
+	})
+	t.Run("Matching copy", func(t *testing.T) {
+		// an earlier Create which couldn't clean up left the copy behind
+		fit, directory := setup(t)
+		if err := os.WriteFile(filepath.Join(directory, "restore.tmp.original"), []byte(contents), 0640); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		original, err := fit.copyOriginal(directory, "restore.tmp")
+		if err != nil || !original.Exists || original.Backup != "restore.tmp.original" {
+			t.Errorf("copyOriginal() is %+v, %v; want the existing copy reused", original, err)
+		}
+	})
+	t.Run("Failed create", func(t *testing.T) {
+		// the owner doesn't exist, so Create fails after the file is written
+		fit, directory := setup(t)
+		req := getCreateRequest(t, map[string]string{
+			"id":               defaultID,
+			"name":             "restore.tmp",
+			"directory":        directory,
+			"permissions":      defaultPerm,
+			"contents":         "this is a restore test",
+			"owner":            "this-user-does-not-exist",
+			"protected":        defaultProtected,
+			"hmac_secret_key":  defaultHmacSecretKey,
+			"destroy_behavior": "restore",
+		})
+		resp := getCreateResponseContainer()
+		fit.Create(context.Background(), req, &resp)
+		if !resp.Diagnostics.HasError() {
+			t.Fatalf("Create() succeeded; want an error for the owner")
+		}
+		perm, restored, err := fit.client.Read(directory, "restore.tmp")
+		if err != nil || restored != contents || perm != "0640" {
+			t.Errorf("Create() left %q with mode %s, %v; want the original %q with mode 0640", restored, perm, err, contents)
+		}
+		if _, err := os.Stat(filepath.Join(directory, "restore.tmp.original")); !os.IsNotExist(err) {
+			t.Errorf("Create() left the copy: %v", err)
+		}
+	})
+	t.Run("No original", func(t *testing.T) {
+		fit := LocalResource{client: &c.OsFileClient{}}
+		directory := t.TempDir()
+		original, err := fit.copyOriginal(directory, "restore.tmp")
+		if err != nil || original.Exists {
+			t.Fatalf("copyOriginal() is %+v, %v; want nothing to restore", original, err)
+		}
+		replace(t, fit, directory)
+		if err := fit.putBackOriginal(original, directory, "restore.tmp"); err != nil {
+			t.Fatalf("putBackOriginal() error: %v", err)
+		}
+		if entries, err := os.ReadDir(directory); err != nil || len(entries) != 0 {
+			t.Errorf("putBackOriginal() left %v, %v; want the file deleted", entries, err)
+		}
+	})
+}
+
+// TestLocalResourceParentDirectories creates, moves, and destroys a file in directories which don't exist yet.
+func TestLocalResourceParentDirectories(t *testing.T) {
+	root := t.TempDir()
+	fit := LocalResource{client: &c.OsFileClient{}}
+	createReq := getCreateRequest(t, map[string]string{
+		"id":                        defaultID,
+		"name":                      "parents.tmp",
+		"directory":                 filepath.Join(root, "a", "b"),
+		"permissions":               defaultPerm,
+		"contents":                  "this is a parent directories test",
+		"protected":                 defaultProtected,
+		"hmac_secret_key":           defaultHmacSecretKey,
+		"create_parent_directories": "true",
+		"directory_permissions":     "0750",
+	})
+	createResp := getCreateResponseContainer()
+	fit.Create(context.Background(), createReq, &createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create() errors: %v", createResp.Diagnostics)
+	}
+	var created LocalResourceModel
+	createResp.State.Get(context.Background(), &created)
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "a", "b")}
+	var got []string
+	created.CreatedDirectories.ElementsAs(context.Background(), &got, false)
+	if !slices.Equal(got, want) {
+		t.Errorf("Create() created_directories are %v; want %v", got, want)
+	}
+	for _, directory := range want {
+		if info, err := os.Stat(directory); err != nil || info.Mode().Perm() != 0750 {
+			t.Errorf("Create() made %s with %v, %v; want mode 0750", directory, info, err)
+		}
+	}
+	if contents, err := os.ReadFile(filepath.Join(root, "a", "b", "parents.tmp")); err != nil || string(contents) != "this is a parent directories test" {
+		t.Errorf("Create() wrote %q, %v", contents, err)
+	}
+
+	// moving the file creates the new directories and removes the empty ones it left
+	updateReq := resource.UpdateRequest{State: createResp.State, Plan: tfsdk.Plan{Raw: createResp.State.Raw, Schema: createResp.State.Schema}}
+	updateReq.Plan.SetAttribute(context.Background(), path.Root("directory"), filepath.Join(root, "c", "d"))
+	updateReq.Plan.SetAttribute(context.Background(), path.Root("created_directories"), types.ListUnknown(types.StringType))
+	updateResp := getUpdateResponseContainer()
+	fit.Update(context.Background(), updateReq, &updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("Update() errors: %v", updateResp.Diagnostics)
+	}
+	var updated LocalResourceModel
+	updateResp.State.Get(context.Background(), &updated)
+	want = []string{filepath.Join(root, "c"), filepath.Join(root, "c", "d")}
+	updated.CreatedDirectories.ElementsAs(context.Background(), &got, false)
+	if !slices.Equal(got, want) {
+		t.Errorf("Update() created_directories are %v; want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a")); !os.IsNotExist(err) {
+		t.Errorf("Update() left the directories created for the old path: %v", err)
+	}
+	if contents, err := os.ReadFile(filepath.Join(root, "c", "d", "parents.tmp")); err != nil || string(contents) != "this is a parent directories test" {
+		t.Errorf("Update() moved %q, %v", contents, err)
+	}
+
+	deleteResp := getDeleteResponseContainer()
+	fit.Delete(context.Background(), resource.DeleteRequest{State: updateResp.State}, &deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Fatalf("Delete() errors: %v", deleteResp.Diagnostics)
+	}
+	if entries, err := os.ReadDir(root); err != nil || len(entries) != 0 {
+		t.Errorf("Delete() left %v, %v behind", entries, err)
+	}
+}
+
+func TestLocalResourceText(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lineEndings string
+		encoding    string
+		contents    string
+		want        string // the bytes written to the file
+	}{
+		{"Preserve", "preserve", "utf-8", "one\r\ntwo\n", "one\r\ntwo\n"},
+		{"LF", "lf", "utf-8", "one\r\ntwo\n", "one\ntwo\n"},
+		{"CRLF", "crlf", "utf-8", "one\ntwo\r\n", "one\r\ntwo\r\n"},
+		{"UTF-8 BOM", "preserve", "utf-8-bom", "caf\u00e9\n", "\xef\xbb\xbfcaf\xc3\xa9\n"},
+		{"UTF-16LE", "crlf", "utf-16le", "h\u00e9\n", "\xff\xfeh\x00\xe9\x00\r\x00\n\x00"},
+		{"Latin1", "preserve", "latin1", "caf\u00e9\n", "caf\xe9\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &c.MemoryFileClient{}
+			fit := LocalResource{client: client}
+			file := map[string]string{
+				"id":              defaultID,
+				"name":            "text.tmp",
+				"directory":       defaultDirectory,
+				"permissions":     defaultPerm,
+				"contents":        tc.contents,
+				"protected":       defaultProtected,
+				"hmac_secret_key": defaultHmacSecretKey,
+				"line_endings":    tc.lineEndings,
+				"encoding":        tc.encoding,
+			}
+			createResp := getCreateResponseContainer()
+			fit.Create(context.Background(), getCreateRequest(t, file), &createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create() errors: %v", createResp.Diagnostics)
+			}
+			_, got, err := client.Read(defaultDirectory, "text.tmp")
+			if err != nil {
+				t.Fatalf("Error reading file: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Create() wrote %q; want %q", got, tc.want)
+			}
+			// the converted file matches the contents, so reading it doesn't show drift
+			readResp := getReadResponseContainer()
+			fit.Read(context.Background(), resource.ReadRequest{State: createResp.State}, &readResp)
+			if readResp.Diagnostics.HasError() {
+				t.Fatalf("Read() errors: %v", readResp.Diagnostics)
+			}
+			var created, read LocalResourceModel
+			createResp.State.Get(context.Background(), &created)
+			readResp.State.Get(context.Background(), &read)
+			if read.Contents != created.Contents || read.ID != created.ID {
+				t.Errorf("Read() contents %q with id %q; want %q with id %q", read.Contents, read.ID, created.Contents, created.ID)
+			}
+		})
+		t.Run(tc.name+" hashed", func(t *testing.T) {
+			// only the hash is kept, it is compared to the hash of the text read back from the file
+			fit := LocalResource{client: &c.MemoryFileClient{}}
+			req := getCreateRequest(t, map[string]string{
+				"id":                  defaultID,
+				"name":                "text.tmp",
+				"directory":           defaultDirectory,
+				"permissions":         defaultPerm,
+				"contents_wo_version": "1",
+				"protected":           defaultProtected,
+				"hmac_secret_key":     defaultHmacSecretKey,
+				"line_endings":        tc.lineEndings,
+				"encoding":            tc.encoding,
+				"store_contents":      "false",
+			})
+			req.Config = getConfig(t, map[string]string{
+				"name":                "text.tmp",
+				"contents_wo":         tc.contents,
+				"contents_wo_version": "1",
+				"line_endings":        tc.lineEndings,
+				"encoding":            tc.encoding,
+				"store_contents":      "false",
+			})
+			createResp := getCreateResponseContainer()
+			fit.Create(context.Background(), req, &createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create() errors: %v", createResp.Diagnostics)
+			}
+			readResp := getReadResponseContainer()
+			fit.Read(context.Background(), resource.ReadRequest{State: createResp.State}, &readResp)
+			if readResp.Diagnostics.HasError() {
+				t.Fatalf("Read() errors: %v", readResp.Diagnostics)
+			}
+			var created, read LocalResourceModel
+			createResp.State.Get(context.Background(), &created)
+			readResp.State.Get(context.Background(), &read)
+			if read.ContentsSha256 != created.ContentsSha256 || read.ID != created.ID {
+				t.Errorf("Read() contents_sha256 %q with id %q; want %q with id %q", read.ContentsSha256, read.ID, created.ContentsSha256, created.ID)
+			}
+		})
+	}
+	t.Run("Latin1 can't encode", func(t *testing.T) {
+		fit := LocalResource{client: &c.MemoryFileClient{}}
+		resp := getCreateResponseContainer()
+		fit.Create(context.Background(), getCreateRequest(t, map[string]string{
+			"id":              defaultID,
+			"name":            "text.tmp",
+			"directory":       defaultDirectory,
+			"permissions":     defaultPerm,
+			"contents":        "\u20ac",
+			"protected":       defaultProtected,
+			"hmac_secret_key": defaultHmacSecretKey,
+			"encoding":        "latin1",
+		}), &resp)
+		if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), "can't be encoded in latin1") {
+			t.Errorf("Create() errors are %v; want an error about latin1", resp.Diagnostics)
+		}
+	})
+}
+
+func TestLocalResourceEncryption(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	testKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // printf '0123456789abcdef0123456789abcdef' | base64
+	testCases := []struct {
+		name       string
+		encryption *LocalEncryptionModel
+	}{
+		{
+			"AES",
+			&LocalEncryptionModel{
+				Recipients: types.ListNull(types.StringType),
+				Key:        types.StringValue(testKey),
+				Identity:   types.StringNull(),
+			},
+		},
+		{
+			"Age",
+			&LocalEncryptionModel{
+				Recipients: types.ListValueMust(types.StringType, []attr.Value{types.StringValue(identity.Recipient().String())}),
+				Key:        types.StringNull(),
+				Identity:   types.StringValue(identity.String()),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &c.MemoryFileClient{}
+			fit := LocalResource{client: client}
+			contents := "this is an encryption test"
+			req := getCreateRequest(t, map[string]string{
+				"id":              defaultID,
+				"name":            "encrypted.tmp",
+				"directory":       defaultDirectory,
+				"permissions":     defaultPerm,
+				"contents":        contents,
+				"protected":       defaultProtected,
+				"hmac_secret_key": defaultHmacSecretKey,
+			})
+			req.Plan.SetAttribute(context.Background(), path.Root("encryption"), tc.encryption)
+			createResp := getCreateResponseContainer()
+			fit.Create(context.Background(), req, &createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create() errors: %v", createResp.Diagnostics)
+			}
+			_, written, err := client.Read(defaultDirectory, "encrypted.tmp")
+			if err != nil {
+				t.Fatalf("Error reading file: %v", err)
+			}
+			if strings.Contains(written, contents) {
+				t.Errorf("Create() wrote the plaintext %q", written)
+			}
+
+			// the decrypted file matches the contents, so reading it doesn't show drift
+			readResp := getReadResponseContainer()
+			fit.Read(context.Background(), resource.ReadRequest{State: createResp.State}, &readResp)
+			if readResp.Diagnostics.HasError() {
+				t.Fatalf("Read() errors: %v", readResp.Diagnostics)
+			}
+			var created, read LocalResourceModel
+			createResp.State.Get(context.Background(), &created)
+			readResp.State.Get(context.Background(), &read)
+			if read.Contents != created.Contents || read.ID != created.ID {
+				t.Errorf("Read() contents %q with id %q; want %q with id %q", read.Contents, read.ID, created.Contents, created.ID)
+			}
+
+			// an unchanged file encrypted with another key is rewritten
+			if tc.encryption.Key.IsNull() {
+				return
+			}
+			updateReq := resource.UpdateRequest{State: createResp.State, Plan: tfsdk.Plan{Raw: createResp.State.Raw, Schema: createResp.State.Schema}}
+			otherKey := "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=" // printf 'fedcba9876543210fedcba9876543210' | base64
+			updateReq.Plan.SetAttribute(context.Background(), path.Root("encryption").AtName("key"), otherKey)
+			updateResp := getUpdateResponseContainer()
+			fit.Update(context.Background(), updateReq, &updateResp)
+			if updateResp.Diagnostics.HasError() {
+				t.Fatalf("Update() errors: %v", updateResp.Diagnostics)
+			}
+			_, rewritten, err := client.Read(defaultDirectory, "encrypted.tmp")
+			if err != nil {
+				t.Fatalf("Error reading file: %v", err)
+			}
+			if plaintext, err := encryption.Decrypt(rewritten, "", otherKey); err != nil || plaintext != contents {
+				t.Errorf("Update() wrote %q which decrypts to %q, %v; want it encrypted with the new key", rewritten, plaintext, err)
+			}
+		})
+	}
+	t.Run("Replaced by plaintext", func(t *testing.T) {
+		client := &c.MemoryFileClient{}
+		fit := LocalResource{client: client}
+		req := getCreateRequest(t, map[string]string{
+			"id":              defaultID,
+			"name":            "encrypted.tmp",
+			"directory":       defaultDirectory,
+			"permissions":     defaultPerm,
+			"contents":        "this is an encryption test",
+			"protected":       defaultProtected,
+			"hmac_secret_key": defaultHmacSecretKey,
+		})
+		req.Plan.SetAttribute(context.Background(), path.Root("encryption"), testCases[0].encryption)
+		createResp := getCreateResponseContainer()
+		fit.Create(context.Background(), req, &createResp)
+		if createResp.Diagnostics.HasError() {
+			t.Fatalf("Create() errors: %v", createResp.Diagnostics)
+		}
+		if err := client.Create(defaultDirectory, "encrypted.tmp", "this is an encryption test", defaultPerm); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+		readResp := resource.ReadResponse{State: createResp.State}
+		fit.Read(context.Background(), resource.ReadRequest{State: createResp.State}, &readResp)
+		if readResp.Diagnostics.HasError() || readResp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("Read() diagnostics are %v; want a warning", readResp.Diagnostics)
+		}
+		if !readResp.State.Raw.IsNull() {
+			t.Errorf("Read() kept the unencrypted file in state")
+		}
+	})
+}
+
+func TestLocalResourceMoveState(t *testing.T) {
+	testCases := []struct {
+		name      string
+		provider  string
+		typeName  string
+		state     string // the JSON state of the source resource
+		want      map[string]string
+		wantError string
+	}{
+		{
+			"local_file",
+			"registry.terraform.io/hashicorp/local",
+			"local_file",
+			`{"filename":"/tmp/move/moved.tmp","content":"this is a move test","file_permission":"0644","directory_permission":"0755"}`,
+			map[string]string{
+				"id":                    "e641d34432712ea98dbf2b65d47ef55bb88b31d7c680d65f9bd00019fa77df68",
+				"name":                  "moved.tmp",
+				"directory":             "/tmp/move",
+				"contents":              "this is a move test",
+				"permissions":           "0644",
+				"directory_permissions": "0755",
+			},
+			"",
+		},
+		{
+			"local_file sensitive_content",
+			"registry.opentofu.org/hashicorp/local",
+			"local_file",
+			`{"filename":"moved.tmp","content":null,"sensitive_content":"this is a move test","file_permission":"0600"}`,
+			map[string]string{
+				"id":          "e641d34432712ea98dbf2b65d47ef55bb88b31d7c680d65f9bd00019fa77df68",
+				"name":        "moved.tmp",
+				"directory":   ".",
+				"contents":    "this is a move test",
+				"permissions": "0600",
+			},
+			"",
+		},
+		{
+			"local_sensitive_file base64",
+			"registry.terraform.io/hashicorp/local",
+			"local_sensitive_file",
+			`{"filename":"/tmp/move/binary.tmp","content_base64":"AAEC//4=","file_permission":"0700","directory_permission":"0700"}`,
+			map[string]string{
+				"id":                    "6af26c370aa28039344b9b153d9d4e687f1d346777228c971c4ce1ca56e765b2",
+				"name":                  "binary.tmp",
+				"directory":             "/tmp/move",
+				"contents_base64":       "AAEC//4=",
+				"permissions":           "0700",
+				"directory_permissions": "0700",
+			},
+			"",
+		},
+		{
+			"Other provider",
+			"registry.terraform.io/hashicorp/null",
+			"local_file",
+			`{"filename":"/tmp/move/moved.tmp","content":"this is a move test"}`,
+			nil,
+			"",
+		},
+		{
+			"No contents",
+			"registry.terraform.io/hashicorp/local",
+			"local_file",
+			`{"filename":"/tmp/move/moved.tmp","file_permission":"0644"}`,
+			nil,
+			"doesn't have content",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fit := LocalResource{client: &c.MemoryFileClient{}}
+			movers := fit.MoveState(context.Background())
+			if len(movers) != 1 {
+				t.Fatalf("MoveState() has %d movers; want 1", len(movers))
+			}
+			req := resource.MoveStateRequest{
+				SourceProviderAddress: tc.provider,
+				SourceTypeName:        tc.typeName,
+				SourceRawState:        &tfprotov6.RawState{JSON: []byte(tc.state)},
+			}
+			resp := resource.MoveStateResponse{TargetState: tfsdk.State{Schema: getLocalResourceSchema().Schema}}
+			resp.TargetState.Raw = tftypes.NewValue(getObjectAttributeTypes(), nil)
+			movers[0].StateMover(context.Background(), req, &resp)
+			if tc.wantError != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), tc.wantError) {
+					t.Fatalf("MoveState() errors are %v; want an error containing %q", resp.Diagnostics, tc.wantError)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("MoveState() errors: %v", resp.Diagnostics)
+			}
+			if tc.want == nil {
+				// the framework reports resources which no mover handled
+				if !resp.TargetState.Raw.IsNull() {
+					t.Errorf("MoveState() moved a %s from %s", tc.typeName, tc.provider)
+				}
+				return
+			}
+			for attribute, want := range tc.want {
+				var got types.String
+				resp.Diagnostics.Append(resp.TargetState.GetAttribute(context.Background(), path.Root(attribute), &got)...)
+				if got.ValueString() != want {
+					t.Errorf("MoveState() %s is %q; want %q", attribute, got.ValueString(), want)
+				}
+			}
+			var createParentDirectories types.Bool
+			resp.Diagnostics.Append(resp.TargetState.GetAttribute(context.Background(), path.Root("create_parent_directories"), &createParentDirectories)...)
+			if !createParentDirectories.ValueBool() {
+				t.Errorf("MoveState() create_parent_directories is %v; want true, like local_file", createParentDirectories)
+			}
+			if resp.Diagnostics.HasError() {
+				t.Errorf("Error getting attributes: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestLocalResourcePlanComputedLists(t *testing.T) {
+	backups := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("/tmp/plan_lists/lists.tmp.1")})
+	created := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("/tmp/plan_lists")})
+	testCases := []struct {
+		name        string
+		change      map[string]string // the planned changes to the state
+		noBackup    bool              // the backup block is removed
+		wantBackups types.List
+		wantCreated types.List
+	}{
+		{"Unchanged", nil, false, backups, created},
+		{"Contents", map[string]string{"contents": "this is a changed plan lists test"}, false, types.ListUnknown(types.StringType), created},
+		{"Backup removed", nil, true, types.ListNull(types.StringType), created},
+		{"Moved", map[string]string{"directory": "/tmp/other"}, false, types.ListUnknown(types.StringType), types.ListUnknown(types.StringType)},
+		{"Stop creating directories", map[string]string{"create_parent_directories": "false"}, false, types.ListUnknown(types.StringType), types.ListNull(types.StringType)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fit := LocalResource{client: &c.MemoryFileClient{}}
+			// empty strings are unknown in the plan, so the id and key have values
+			state := map[string]string{
+				"id":                        "this-is-the-planned-id",
+				"name":                      "lists.tmp",
+				"directory":                 "/tmp/plan_lists",
+				"permissions":               defaultPerm,
+				"contents":                  "this is a plan lists test",
+				"protected":                 defaultProtected,
+				"hmac_secret_key":           "this-is-the-planned-key",
+				"create_parent_directories": "true",
+			}
+			plan := maps.Clone(state)
+			maps.Copy(plan, tc.change)
+			req := getModifyPlanRequest(t, map[string]map[string]string{"config": plan, "plan": plan, "state": state})
+			backupBlock := &LocalBackupModel{Count: types.Int64Value(1), SuffixFormat: types.StringValue("numbered")}
+			req.State.SetAttribute(context.Background(), path.Root("backup"), backupBlock)
+			req.State.SetAttribute(context.Background(), path.Root("backups"), backups)
+			req.State.SetAttribute(context.Background(), path.Root("created_directories"), created)
+			if !tc.noBackup {
+				req.Plan.SetAttribute(context.Background(), path.Root("backup"), backupBlock)
+			}
+			// UseStateForUnknown has already copied the lists from the state
+			req.Plan.SetAttribute(context.Background(), path.Root("backups"), backups)
+			req.Plan.SetAttribute(context.Background(), path.Root("created_directories"), created)
+			resp := resource.ModifyPlanResponse{Plan: req.Plan}
+			fit.planComputedLists(context.Background(), req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("planComputedLists() errors: %v", resp.Diagnostics)
+			}
+			var gotBackups, gotCreated types.List
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(context.Background(), path.Root("backups"), &gotBackups)...)
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(context.Background(), path.Root("created_directories"), &gotCreated)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Error getting attributes: %v", resp.Diagnostics)
+			}
+			if !gotBackups.Equal(tc.wantBackups) {
+				t.Errorf("planComputedLists() backups are %v; want %v", gotBackups, tc.wantBackups)
+			}
+			if !gotCreated.Equal(tc.wantCreated) {
+				t.Errorf("planComputedLists() created_directories are %v; want %v", gotCreated, tc.wantCreated)
+			}
+		})
+	}
+}
+
+func TestLocalResourceValidateConfig(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    map[string]string
+		wantError string // the attribute with the error
+	}{
+		{"Stored contents", map[string]string{"name": "validate.tmp", "contents": "this is a validate test"}, ""},
+		{"Hash only contents", map[string]string{"name": "validate.tmp", "contents": "this is a validate test", "store_contents": "false"}, "contents"},
+		{"Hash only base64", map[string]string{"name": "validate.tmp", "contents_base64": "AAEC", "store_contents": "false"}, "contents_base64"},
+		{"Hash only write-only", map[string]string{"name": "validate.tmp", "contents_wo": "this is a validate test", "contents_wo_version": "1", "store_contents": "false"}, ""},
+		{"Hash only source", map[string]string{"name": "validate.tmp", "source": "/tmp/source/artifact.bin", "store_contents": "false"}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fit := LocalResource{client: &c.MemoryFileClient{}}
+			resp := resource.ValidateConfigResponse{}
+			fit.ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: getConfig(t, tc.config)}, &resp)
+			if tc.wantError == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("ValidateConfig() errors: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.ErrorsCount() != 1 || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), "'"+tc.wantError+"'") {
+				t.Errorf("ValidateConfig() errors are %v; want an error for %s", resp.Diagnostics, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestLocalResourceModifyPlan(t *testing.T) {
+	t.Run("ModifyPlan function", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			fit  LocalResource
+			have resource.ModifyPlanRequest
+			want map[string]string // an empty map expects an error
+		}{
+			{
+				"Provider defaults",
+				LocalResource{
+					client: &c.MemoryFileClient{},
+					providerData: &provider_data.ProviderData{
+						RootDirectory:          "/tmp/root",
+						DefaultFilePermissions: "0640",
+						DefaultOwner:           "1000",
+						DefaultGroup:           "1000",
+					},
+				},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":     "modify_plan.tmp",
+						"contents": "this is a modify plan test",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"directory":   "/tmp/root",
+					"permissions": "0640",
+					"owner":       "1000",
+					"group":       "1000",
+				},
+			},
+			{
+				"Resource arguments override provider defaults",
+				LocalResource{
+					client:       &c.MemoryFileClient{},
+					providerData: &provider_data.ProviderData{RootDirectory: "/tmp/root", DefaultFilePermissions: "0640"},
+				},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":        "modify_plan.tmp",
+						"contents":    "this is a modify plan test",
+						"directory":   "/tmp/other",
+						"permissions": "0644",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       "/tmp/other",
+						"permissions":     "0644",
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"directory":   "/tmp/other",
+					"permissions": "0644",
+				},
+			},
+			{
+				"No provider configuration",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":     "modify_plan.tmp",
+						"contents": "this is a modify plan test",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"directory":   defaultDirectory,
+					"permissions": defaultPerm,
+				},
+			},
+			{
+				"Path outside sandbox",
+				LocalResource{
+					client:       &c.MemoryFileClient{},
+					providerData: &provider_data.ProviderData{Sandbox: testSandbox(t)},
+				},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":      "passwd",
+						"contents":  "this is a modify plan test",
+						"directory": "../../../../../../../../etc",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "passwd",
+						"directory":       "../../../../../../../../etc",
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{},
+			},
+			{
+				"Source hash",
+				LocalResource{client: sourceClient(t, "/tmp/source/artifact.bin", "this is a source test")},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":   "modify_plan.tmp",
+						"source": "/tmp/source/artifact.bin",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"source":          "/tmp/source/artifact.bin",
+						"source_sha256":   "", // unknown
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"directory":     defaultDirectory,
+					"permissions":   defaultPerm,
+					"source_sha256": "2d789add166484b8bc8987f50308431d59dd437bdd65b2e1ddc66def9049469d",
+				},
+			},
+			{
+				"Hash only contents change",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":                "modify_plan.tmp",
+						"contents_wo":         "this is a changed hash only test",
+						"contents_wo_version": "1",
+						"store_contents":      "false",
+					},
+					"plan": {
+						"id":                  defaultID,
+						"name":                "modify_plan.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"store_contents":      "false",
+						"contents_sha256":     "", // unknown
+						"protected":           defaultProtected,
+						"hmac_secret_key":     defaultHmacSecretKey,
+					},
+					"state": {
+						"id":                  "c98302c03aea8b68c63753d8370af129d502ec6a19439e6b23a18d857c8173e2",
+						"name":                "modify_plan.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"store_contents":      "false",
+						"contents_sha256":     "5c9a684707ba4e3b6dac2a9bf312435cf93f8b24cc3bd1b6043dba7a4e4bb4c2",
+						"protected":           defaultProtected,
+						"hmac_secret_key":     defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"directory":        defaultDirectory,
+					"permissions":      defaultPerm,
+					"contents_sha256":  "13cd8aba3c65b9592f72289a5a2f293730dde1b645638e351cfccef6f375749f",
+					"requires_replace": "contents_sha256",
+				},
+			},
+			{
+				"Unprotected id",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":     "modify_plan.tmp",
+						"contents": "this is a modify plan test",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+					},
+				}),
+				// want
+				map[string]string{
+					"id":          "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+					"directory":   defaultDirectory,
+					"permissions": defaultPerm,
 				},
 			},
 			{
-				"Protected with mode update",
+				"Protected id mismatch",
 				LocalResource{client: &c.MemoryFileClient{}},
 				// have
-				getReadRequest(t, map[string]string{
-					"id":              "ec4407ba53b2c40ac2ac18ff7372a6fe6e4f7f8aa04f340503aefc7d9a5fa4e1",
-					"name":            "read_protected_mode.tmp",
-					"directory":       defaultDirectory,
-					"permissions":     defaultPerm,
-					"contents":        "this is a protected read test",
-					"protected":       "true",
-					"hmac_secret_key": "this-is-a-test-key",
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"id":              "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+						"name":            "modify_plan.tmp",
+						"contents":        "this is a modify plan test",
+						"protected":       "true",
+						"hmac_secret_key": "this-is-a-test-key",
+					},
+					"plan": {
+						"id":              "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       "true",
+						"hmac_secret_key": "this-is-a-test-key",
+					},
 				}),
-				// want
-				getReadResponse(t, map[string]string{
-					"id":              "ec4407ba53b2c40ac2ac18ff7372a6fe6e4f7f8aa04f340503aefc7d9a5fa4e1",
-					"name":            "read_protected_mode.tmp",
-					"directory":       defaultDirectory,
-					"permissions":     "0755",
-					"contents":        "this is a protected read test",
-					"protected":       "true",
-					"hmac_secret_key": "this-is-a-test-key",
+				// want, an error before anything is applied
+				map[string]string{},
+			},
+			{
+				"Existing file fails",
+				LocalResource{client: sourceClient(t, "modify_plan.tmp", "this file was here first")}, // the existing file
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":      "modify_plan.tmp",
+						"contents":  "this is a modify plan test",
+						"if_exists": "fail",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+						"if_exists":       "fail",
+					},
 				}),
-				// reality
+				// want, an error before the file is overwritten
+				map[string]string{},
+			},
+			{
+				"Existing file adopted",
+				LocalResource{client: sourceClient(t, "modify_plan.tmp", "this file was here first")}, // the existing file
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":      "modify_plan.tmp",
+						"contents":  "this is a modify plan test",
+						"if_exists": "adopt",
+					},
+					"plan": {
+						"id":              defaultID,
+						"name":            "modify_plan.tmp",
+						"directory":       defaultDirectory,
+						"permissions":     defaultPerm,
+						"contents":        "this is a modify plan test",
+						"protected":       defaultProtected,
+						"hmac_secret_key": defaultHmacSecretKey,
+						"if_exists":       "adopt",
+					},
+				}),
+				// want, a warning rather than an error
 				map[string]string{
-					"mode":      "0755",
-					"directory": defaultDirectory,
-					"name":      "read_protected_mode.tmp",
-					"contents":  "this is a protected read test",
+					"id":          "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+					"directory":   defaultDirectory,
+					"permissions": defaultPerm,
+					"warning":     "Adopting existing file",
 				},
 			},
-		}
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
-					t.Errorf("Error setting up: %v", err)
-				}
-				defer func() {
-					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
-						t.Errorf("Error tearing down: %v", err)
-					}
-				}()
-				r := getReadResponseContainer()
-				tc.fit.Read(context.Background(), tc.have, &r)
-				got := r
-				if diff := cmp.Diff(tc.want, got); diff != "" {
-					t.Errorf("Read() mismatch (-want +got):\n%s", diff)
-				}
-			})
-		}
-	})
-}
-func TestLocalResourceUpdate(t *testing.T) {
-	t.Run("Update function", func(t *testing.T) {
-		testCases := []struct {
-			name  string
-			fit   LocalResource
-			have  resource.UpdateRequest
-			want  resource.UpdateResponse
-			setup map[string]string
-		}{
 			{
-				"Basic test",
-				LocalResource{client: &c.MemoryFileClient{}},
+				"Existing file adopted keeps its attributes",
+				LocalResource{client: adoptClient(t, "modify_plan.tmp", "0644", "1234", "5678")},
 				// have
-				getUpdateRequest(t, map[string]map[string]string{
-					"priorState": {
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":      "modify_plan.tmp",
+						"contents":  "this is a modify plan test",
+						"if_exists": "adopt",
+					},
+					"plan": {
 						"id":              defaultID,
-						"name":            "update_basic.tmp",
+						"name":            "modify_plan.tmp",
 						"directory":       defaultDirectory,
 						"permissions":     defaultPerm,
-						"contents":        "this is an update test",
+						"contents":        "this is a modify plan test",
 						"protected":       defaultProtected,
 						"hmac_secret_key": defaultHmacSecretKey,
+						"if_exists":       "adopt",
+					},
+				}),
+				// want, the unconfigured attributes of the existing file rather than the defaults
+				map[string]string{
+					"id":          "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+					"directory":   defaultDirectory,
+					"permissions": "0644",
+					"owner":       "1234",
+					"group":       "5678",
+					"warning":     "Adopting existing file",
+				},
+			},
+			{
+				"Existing file adopted with configured attributes",
+				LocalResource{client: adoptClient(t, "modify_plan.tmp", "0644", "1234", "5678")},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":        "modify_plan.tmp",
+						"contents":    "this is a modify plan test",
+						"permissions": "0640",
+						"owner":       "4321",
+						"if_exists":   "adopt",
 					},
 					"plan": {
 						"id":              defaultID,
-						"name":            "update_basic.tmp",
+						"name":            "modify_plan.tmp",
 						"directory":       defaultDirectory,
-						"permissions":     defaultPerm,
-						"contents":        "this is a basic update test",
+						"permissions":     "0640",
+						"owner":           "4321",
+						"contents":        "this is a modify plan test",
 						"protected":       defaultProtected,
 						"hmac_secret_key": defaultHmacSecretKey,
+						"if_exists":       "adopt",
 					},
 				}),
-				// want
-				getUpdateResponse(t, map[string]string{
-					"id":              "0ec41eee6c157a3f7e50b78d586ee2ddb4d6e93b6de8bdf6d9354cf720e89549",
-					"name":            "update_basic.tmp",
-					"directory":       defaultDirectory,
-					"permissions":     defaultPerm,
-					"contents":        "this is a basic update test",
-					"protected":       defaultProtected,
-					"hmac_secret_key": defaultHmacSecretKey,
+				// want, the configuration wins and only the group is adopted
+				map[string]string{
+					"id":          "2c6c2630c8ea81c19913dbb1493e633ad23cb69611622c2e2447e62d14e38dd9",
+					"directory":   defaultDirectory,
+					"permissions": "0640",
+					"owner":       "4321",
+					"group":       "5678",
+					"warning":     "Adopting existing file",
+				},
+			},
+			{
+				"Deprecated create_directories",
+				LocalResource{client: &c.MemoryFileClient{}},
+				// have
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":               "modify_plan.tmp",
+						"contents":           "this is a modify plan test",
+						"create_directories": "true",
+					},
+					"plan": {
+						"id":                        defaultID,
+						"name":                      "modify_plan.tmp",
+						"directory":                 defaultDirectory,
+						"permissions":               defaultPerm,
+						"contents":                  "this is a modify plan test",
+						"protected":                 defaultProtected,
+						"hmac_secret_key":           defaultHmacSecretKey,
+						"create_directories":        "true",
+						"create_parent_directories": "false",
+					},
 				}),
-				// setup
+				// want, the default is replaced by the deprecated name
 				map[string]string{
-					"mode":      defaultPerm,
-					"directory": defaultDirectory,
-					"name":      "update_basic.tmp",
-					"contents":  "this is an update test",
+					"directory":                 defaultDirectory,
+					"permissions":               defaultPerm,
+					"create_parent_directories": "true",
 				},
 			},
-		}
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
-					t.Errorf("Error setting up: %v", err)
-				}
-				defer func() {
-					if err := tc.fit.client.Delete(tc.setup["directory"], tc.setup["name"]); err != nil {
-						t.Errorf("Error tearing down: %v", err)
-					}
-				}()
-				r := getUpdateResponseContainer()
-				tc.fit.Update(context.Background(), tc.have, &r)
-				got := r
-				var plannedState LocalResourceModel
-				if diags := tc.have.Plan.Get(context.Background(), &plannedState); diags.HasError() {
-					t.Errorf("Failed to get planned state: %v", diags)
-				}
-				plannedContents := plannedState.Contents.ValueString()
-				_, contentsAfterUpdate, err := tc.fit.client.Read(plannedState.Directory.ValueString(), plannedState.Name.ValueString())
-				if err != nil {
-					t.Errorf("Failed to read file for update verification: %s", err)
-				}
-				if contentsAfterUpdate != plannedContents {
-					t.Errorf("File content was not updated correctly. Got %q, want %q", contentsAfterUpdate, plannedContents)
-				}
-				if diff := cmp.Diff(tc.want, got); diff != "" {
-					t.Errorf("Update() mismatch (-want +got):\n%s", diff)
-				}
-			})
-		}
-	})
-}
-
-func TestLocalResourceDelete(t *testing.T) {
-	t.Run("Delete function", func(t *testing.T) {
-		testCases := []struct {
-			name  string
-			fit   LocalResource
-			have  resource.DeleteRequest
-			want  resource.DeleteResponse
-			setup map[string]string
-		}{
 			{
-				"Basic test",
+				"Write-only contents hash",
 				LocalResource{client: &c.MemoryFileClient{}},
 				// have
-				getDeleteRequest(t, map[string]string{
-					"id":              "fd6fb8621c4850c228190f4d448ce30881a32609d6b4c7341d48d0027e597567",
-					"name":            "delete.tmp",
-					"directory":       defaultDirectory,
-					"permissions":     defaultPerm,
-					"contents":        "this is a delete test",
-					"protected":       defaultProtected,
-					"hmac_secret_key": defaultHmacSecretKey,
+				getModifyPlanRequest(t, map[string]map[string]string{
+					"config": {
+						"name":                "modify_plan.tmp",
+						"contents_wo":         "this is a changed write only test",
+						"contents_wo_version": "1",
+					},
+					"plan": {
+						"id":                  defaultID,
+						"name":                "modify_plan.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"contents_sha256":     "e8402cb75ce3d6d7b22f6ba1d18ce3f992b4e8b95e180f6afe6d4e7401d28dc6",
+						"protected":           defaultProtected,
+						"hmac_secret_key":     defaultHmacSecretKey,
+					},
+					"state": {
+						"id":                  "4a51f133c88b2a4ff53fea61f35deccd83f9b32c998cc40608d08b48131b965f",
+						"name":                "modify_plan.tmp",
+						"directory":           defaultDirectory,
+						"permissions":         defaultPerm,
+						"contents_wo_version": "1",
+						"contents_sha256":     "e8402cb75ce3d6d7b22f6ba1d18ce3f992b4e8b95e180f6afe6d4e7401d28dc6",
+						"protected":           defaultProtected,
+						"hmac_secret_key":     defaultHmacSecretKey,
+					},
 				}),
-				// want
-				getDeleteResponse(),
-				// setup
+				// want, the file is updated in place rather than replaced
 				map[string]string{
-					"mode":      defaultPerm,
-					"directory": defaultDirectory,
-					"name":      "delete.tmp",
-					"contents":  "this is a delete test",
+					"directory":       defaultDirectory,
+					"permissions":     defaultPerm,
+					"contents_sha256": "ed2a3df8c829f70d1257a918dc1f09c3a63e39ffe90661c4269d21ad8b8e68c8",
 				},
 			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				if err := tc.fit.client.Create(tc.setup["directory"], tc.setup["name"], tc.setup["contents"], tc.setup["mode"]); err != nil {
-					t.Errorf("Error setting up: %v", err)
+				r := resource.ModifyPlanResponse{Plan: tc.have.Plan}
+				tc.fit.ModifyPlan(context.Background(), tc.have, &r)
+				if len(tc.want) == 0 {
+					if !r.Diagnostics.HasError() {
+						t.Errorf("ModifyPlan() expected an error, got none")
+					}
+					return
 				}
-				r := getDeleteResponseContainer()
-				tc.fit.Delete(context.Background(), tc.have, &r)
-				got := r
-				// Verify the file was actually deleted from disk
-				if _, c, err := tc.fit.client.Read(tc.setup["directory"], tc.setup["name"]); err == nil || err.Error() != "file not found" {
-					if err == nil {
-						t.Errorf("Expected file to be delete, but it still exists. File contents: %s", c)
+				if r.Diagnostics.HasError() {
+					t.Errorf("ModifyPlan() returned errors: %v", r.Diagnostics)
+					return
+				}
+				if want := tc.want["warning"]; want != "" {
+					if warnings := r.Diagnostics.Warnings(); len(warnings) != 1 || warnings[0].Summary() != want {
+						t.Errorf("ModifyPlan() warnings are %v; want %q", warnings, want)
 					}
-					t.Errorf("Expected file to be deleted, but it still exists. Error: %s", err.Error())
 				}
-				// verify that the file was removed from state
-				if diff := cmp.Diff(tc.want, got); diff != "" {
-					t.Errorf("Update() mismatch (-want +got):\n%s", diff)
+				var got LocalResourceModel
+				if diags := r.Plan.Get(context.Background(), &got); diags.HasError() {
+					t.Errorf("Failed to get modified plan: %v", diags)
+					return
+				}
+				if id, ok := tc.want["id"]; ok && got.ID.ValueString() != id {
+					t.Errorf("ModifyPlan() id is %q; want %q", got.ID.ValueString(), id)
+				}
+				if got.Directory.ValueString() != tc.want["directory"] {
+					t.Errorf("ModifyPlan() directory is %q; want %q", got.Directory.ValueString(), tc.want["directory"])
+				}
+				if got.Permissions.ValueString() != tc.want["permissions"] {
+					t.Errorf("ModifyPlan() permissions is %q; want %q", got.Permissions.ValueString(), tc.want["permissions"])
+				}
+				if got.Owner.ValueString() != tc.want["owner"] || got.Group.ValueString() != tc.want["group"] {
+					t.Errorf("ModifyPlan() owner and group are %q, %q; want %q, %q",
+						got.Owner.ValueString(), got.Group.ValueString(), tc.want["owner"], tc.want["group"])
+				}
+				if want, ok := tc.want["create_parent_directories"]; ok && got.CreateParentDirs.String() != want {
+					t.Errorf("ModifyPlan() create_parent_directories is %s; want %s", got.CreateParentDirs.String(), want)
+				}
+				if got.SourceSha256.ValueString() != tc.want["source_sha256"] {
+					t.Errorf("ModifyPlan() source_sha256 is %q; want %q", got.SourceSha256.ValueString(), tc.want["source_sha256"])
+				}
+				if got.ContentsSha256.ValueString() != tc.want["contents_sha256"] {
+					t.Errorf("ModifyPlan() contents_sha256 is %q; want %q", got.ContentsSha256.ValueString(), tc.want["contents_sha256"])
+				}
+				wantReplace := path.Paths{}
+				if tc.want["requires_replace"] != "" {
+					wantReplace = path.Paths{path.Root(tc.want["requires_replace"])}
+				}
+				if diff := cmp.Diff(wantReplace, append(path.Paths{}, r.RequiresReplace...)); diff != "" {
+					t.Errorf("ModifyPlan() requires replace mismatch (-want +got):\n%s", diff)
 				}
 			})
 		}
@@ -490,6 +2763,8 @@ func getCreateRequest(t *testing.T, data map[string]string) resource.CreateReque
 				}
 				planMap[key] = tftypes.NewValue(tftypes.Bool, v)
 			}
+		} else if slices.Contains(numberFields, key) {
+			planMap[key] = numberValue(t, value)
 		} else {
 			if value == "" {
 				planMap[key] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
@@ -498,7 +2773,7 @@ func getCreateRequest(t *testing.T, data map[string]string) resource.CreateReque
 			}
 		}
 	}
-	planValue := tftypes.NewValue(getObjectAttributeTypes(), planMap)
+	planValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), planMap))
 	return resource.CreateRequest{
 		Plan: tfsdk.Plan{
 			Raw:    planValue,
@@ -520,11 +2795,13 @@ func getCreateResponse(t *testing.T, data map[string]string) resource.CreateResp
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.CreateResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -542,11 +2819,13 @@ func getReadRequest(t *testing.T, data map[string]string) resource.ReadRequest {
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.ReadRequest{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -568,11 +2847,13 @@ func getReadResponse(t *testing.T, data map[string]string) resource.ReadResponse
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.ReadResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -590,11 +2871,13 @@ func getUpdateRequest(t *testing.T, data map[string]map[string]string) resource.
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	priorStateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	priorStateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 
 	planMap := make(map[string]tftypes.Value)
 	for key, value := range data["plan"] {
@@ -608,6 +2891,8 @@ func getUpdateRequest(t *testing.T, data map[string]map[string]string) resource.
 				}
 				planMap[key] = tftypes.NewValue(tftypes.Bool, v)
 			}
+		} else if slices.Contains(numberFields, key) {
+			planMap[key] = numberValue(t, value)
 		} else {
 			if value == "" {
 				planMap[key] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
@@ -616,7 +2901,7 @@ func getUpdateRequest(t *testing.T, data map[string]map[string]string) resource.
 			}
 		}
 	}
-	planValue := tftypes.NewValue(getObjectAttributeTypes(), planMap)
+	planValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), planMap))
 
 	return resource.UpdateRequest{
 		State: tfsdk.State{
@@ -643,11 +2928,13 @@ func getUpdateResponse(t *testing.T, data map[string]string) resource.UpdateResp
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.UpdateResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -665,11 +2952,13 @@ func getDeleteRequest(t *testing.T, data map[string]string) resource.DeleteReque
 				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
 			}
 			stateMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			stateMap[key] = numberValue(t, value)
 		} else {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.DeleteRequest{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -690,16 +2979,155 @@ func getDeleteResponse() resource.DeleteResponse {
 	}
 }
 
+// The config only contains the arguments given, anything missing is null.
+// The state is null unless one is given, as it would be during a create.
+func getModifyPlanRequest(t *testing.T, data map[string]map[string]string) resource.ModifyPlanRequest {
+	update := getUpdateRequest(t, map[string]map[string]string{"plan": data["plan"], "priorState": data["state"]})
+	state := update.State
+	if data["state"] == nil {
+		state.Raw = tftypes.NewValue(getObjectAttributeTypes(), nil)
+	}
+	return resource.ModifyPlanRequest{
+		Config: getConfig(t, data["config"]),
+		Plan:   update.Plan,
+		State:  state,
+	}
+}
+
+// The config only contains the arguments given, anything missing is null.
+// Write-only arguments are only ever found in the config.
+func getConfig(t *testing.T, data map[string]string) tfsdk.Config {
+	configMap := make(map[string]tftypes.Value)
+	for key, attributeType := range getObjectAttributeTypes().AttributeTypes {
+		value, ok := data[key]
+		if !ok {
+			configMap[key] = tftypes.NewValue(attributeType, nil)
+			continue
+		}
+		if slices.Contains(booleanFields, key) { // booleanFields is a constant
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				t.Errorf("Error converting %s to bool %s: ", value, err.Error())
+			}
+			configMap[key] = tftypes.NewValue(tftypes.Bool, v)
+		} else if slices.Contains(numberFields, key) {
+			configMap[key] = numberValue(t, value)
+		} else {
+			configMap[key] = tftypes.NewValue(tftypes.String, value)
+		}
+	}
+	configValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), configMap))
+	return tfsdk.Config{
+		Raw:    configValue,
+		Schema: getLocalResourceSchema().Schema,
+	}
+}
+
+// numberValue converts the value to a number, an empty string is unknown.
+func numberValue(t *testing.T, value string) tftypes.Value {
+	if value == "" {
+		return tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)
+	}
+	v, ok := new(big.Float).SetString(value)
+	if !ok {
+		t.Errorf("Error converting %s to number", value)
+	}
+	return tftypes.NewValue(tftypes.Number, v)
+}
+
+// sourceClient returns a memory client holding the source file.
+func sourceClient(t *testing.T, sourcePath string, contents string) *c.MemoryFileClient {
+	client := &c.MemoryFileClient{}
+	if err := client.Create(filepath.Dir(sourcePath), filepath.Base(sourcePath), contents, defaultPerm); err != nil {
+		t.Fatalf("Error setting up source: %v", err)
+	}
+	return client
+}
+
+// adoptClient returns a client with an existing file to adopt.
+func adoptClient(t *testing.T, name string, perm string, owner string, group string) *c.MemoryFileClient {
+	client := &c.MemoryFileClient{}
+	if err := client.Create(defaultDirectory, name, "this file was here first", perm); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	if err := client.Chown(defaultDirectory, name, owner, group); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	return client
+}
+
+func testSandbox(t *testing.T) *sandbox.Sandbox {
+	s, err := sandbox.New([]string{t.TempDir()}, nil)
+	if err != nil {
+		t.Fatalf("Error creating sandbox: %v", err)
+	}
+	return s
+}
+
+// fillNulls sets every attribute missing from the values to null, test cases only need to list the attributes they use.
+func fillNulls(objectType tftypes.Object, values map[string]tftypes.Value) map[string]tftypes.Value {
+	for key, attributeType := range objectType.AttributeTypes {
+		if _, ok := values[key]; !ok {
+			values[key] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return values
+}
+
 func getObjectAttributeTypes() tftypes.Object {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
-			"id":              tftypes.String,
-			"name":            tftypes.String,
-			"directory":       tftypes.String,
-			"permissions":     tftypes.String,
-			"contents":        tftypes.String,
-			"hmac_secret_key": tftypes.String,
-			"protected":       tftypes.Bool,
+			"id":                          tftypes.String,
+			"name":                        tftypes.String,
+			"directory":                   tftypes.String,
+			"permissions":                 tftypes.String,
+			"owner":                       tftypes.String,
+			"group":                       tftypes.String,
+			"contents":                    tftypes.String,
+			"contents_base64":             tftypes.String,
+			"contents_wo":                 tftypes.String,
+			"contents_wo_version":         tftypes.Number,
+			"source":                      tftypes.String,
+			"source_sha256":               tftypes.String,
+			"store_contents":              tftypes.Bool,
+			"contents_sha256":             tftypes.String,
+			"hmac_secret_key":             tftypes.String,
+			"previous_hmac_secret_key":    tftypes.String,
+			"hmac_algorithm":              tftypes.String,
+			"destroy_behavior":            tftypes.String,
+			"if_exists":                   tftypes.String,
+			"create_directories":          tftypes.Bool,
+			"create_parent_directories":   tftypes.Bool,
+			"directory_permissions":       tftypes.String,
+			"created_directories":         tftypes.List{ElementType: tftypes.String},
+			"modified_time":               tftypes.String,
+			"access_time":                 tftypes.String,
+			"preserve_mtime_if_unchanged": tftypes.Bool,
+			"backups":                     tftypes.List{ElementType: tftypes.String},
+			"lock": tftypes.Object{
+				AttributeTypes: map[string]tftypes.Type{
+					"mode":    tftypes.String,
+					"timeout": tftypes.String,
+				},
+			},
+			"xattrs":          tftypes.Map{ElementType: tftypes.String},
+			"selinux_context": tftypes.String,
+			"line_endings":    tftypes.String,
+			"encoding":        tftypes.String,
+			"encryption": tftypes.Object{
+				AttributeTypes: map[string]tftypes.Type{
+					"recipients": tftypes.List{ElementType: tftypes.String},
+					"key":        tftypes.String,
+					"identity":   tftypes.String,
+				},
+			},
+			"backup": tftypes.Object{
+				AttributeTypes: map[string]tftypes.Type{
+					"count":         tftypes.Number,
+					"suffix_format": tftypes.String,
+				},
+			},
+			"protected": tftypes.Bool,
 		},
 	}
 }
@@ -6,14 +6,20 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"sort"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	c "github.com/rancher/terraform-provider-file/internal/provider/directory_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -28,20 +34,29 @@ func NewLocalDirectoryDataSource() datasource.DataSource {
 }
 
 type LocalDirectoryDataSource struct {
-	client c.DirectoryClient
+	client       c.DirectoryClient
+	providerData *provider_data.ProviderData
 }
 
 type LocalDirectoryDataSourceModel struct {
-	ID          types.String                  `tfsdk:"id"`
-	Path        types.String                  `tfsdk:"path"`
-	Permissions types.String                  `tfsdk:"permissions"`
-	Files       []LocalDirectoryFileInfoModel `tfsdk:"files"`
+	ID             types.String                  `tfsdk:"id"`
+	Path           types.String                  `tfsdk:"path"`
+	Permissions    types.String                  `tfsdk:"permissions"`
+	Owner          types.String                  `tfsdk:"owner"`
+	Group          types.String                  `tfsdk:"group"`
+	Xattrs         types.Map                     `tfsdk:"xattrs"`
+	SelinuxContext types.String                  `tfsdk:"selinux_context"`
+	Files          []LocalDirectoryFileInfoModel `tfsdk:"files"`
+	Exists         types.Bool                    `tfsdk:"exists"`
+	FailIfMissing  types.Bool                    `tfsdk:"fail_if_missing"`
 }
 
 type LocalDirectoryFileInfoModel struct {
 	Name         types.String `tfsdk:"name"`
 	Size         types.String `tfsdk:"size"`
 	Permissions  types.String `tfsdk:"permissions"`
+	Owner        types.String `tfsdk:"owner"`
+	Group        types.String `tfsdk:"group"`
 	LastModified types.String `tfsdk:"last_modified"`
 	IsDirectory  types.String `tfsdk:"is_directory"`
 }
@@ -63,6 +78,24 @@ func (r *LocalDirectoryDataSource) Schema(_ context.Context, _ datasource.Schema
 				MarkdownDescription: "Permissions of the directory.",
 				Computed:            true,
 			},
+			"owner": schema.StringAttribute{
+				MarkdownDescription: "The user which owns the directory, the numeric id is given when it doesn't resolve to a name.",
+				Computed:            true,
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "The group which owns the directory, the numeric id is given when it doesn't resolve to a name.",
+				Computed:            true,
+			},
+			"xattrs": schema.MapAttribute{
+				MarkdownDescription: "Every extended attribute of the directory, including the SELinux context. " +
+					"This is empty when the filesystem doesn't support extended attributes.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"selinux_context": schema.StringAttribute{
+				MarkdownDescription: "The SELinux context of the directory, null when the directory doesn't have one.",
+				Computed:            true,
+			},
 			"files": schema.ListNestedAttribute{
 				MarkdownDescription: "List of information about files in the directory.",
 				Computed:            true,
@@ -80,6 +113,14 @@ func (r *LocalDirectoryDataSource) Schema(_ context.Context, _ datasource.Schema
 							MarkdownDescription: "The file's permissions mode expressed in string format, eg. '0600'. ",
 							Computed:            true,
 						},
+						"owner": schema.StringAttribute{
+							MarkdownDescription: "The user which owns the file, the numeric id is given when it doesn't resolve to a name. ",
+							Computed:            true,
+						},
+						"group": schema.StringAttribute{
+							MarkdownDescription: "The group which owns the file, the numeric id is given when it doesn't resolve to a name. ",
+							Computed:            true,
+						},
 						"last_modified": schema.StringAttribute{
 							MarkdownDescription: "The UTC date of the last time the file was updated. ",
 							Computed:            true,
@@ -96,15 +137,38 @@ func (r *LocalDirectoryDataSource) Schema(_ context.Context, _ datasource.Schema
 				MarkdownDescription: "Identifier derived from sha256 hash of path. ",
 				Computed:            true,
 			},
+			"exists": schema.BoolAttribute{
+				MarkdownDescription: "Whether the directory exists, this is only false when 'fail_if_missing' is false. " +
+					"When the directory doesn't exist every attribute read from the directory is null.",
+				Computed: true,
+			},
+			"fail_if_missing": schema.BoolAttribute{
+				MarkdownDescription: "Whether a missing directory is an error naming its path, defaults to true. " +
+					"Set this to false to check 'exists' instead.",
+				Optional: true,
+				Computed: true,
+			},
 		},
 	}
 }
 
-func (r *LocalDirectoryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+func (r *LocalDirectoryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
+	data, ok := req.ProviderData.(*provider_data.ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider_data.ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	r.providerData = data
+	if client, ok := r.client.(*c.OsDirectoryClient); ok {
+		client.Sandbox = data.Sandbox
+	}
 }
 
 // Read runs before all other resources are run, datasources only get the Read function.
@@ -118,10 +182,32 @@ func (r *LocalDirectoryDataSource) Read(ctx context.Context, req datasource.Read
 	}
 	id := config.ID.ValueString()
 	path := config.Path.ValueString()
+	if config.FailIfMissing.IsNull() {
+		config.FailIfMissing = types.BoolValue(true)
+	}
+
+	if id == "" {
+		hasher := sha256.New()
+		hasher.Write([]byte(path))
+		id = hex.EncodeToString(hasher.Sum(nil))
+		config.ID = types.StringValue(id)
+	}
 
 	perm, files, err := r.client.Read(path)
-	if err != nil && err.Error() == "directory not found" {
-		resp.State.RemoveResource(ctx)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		if config.FailIfMissing.ValueBool() {
+			resp.Diagnostics.AddError("Error reading directory: ",
+				fmt.Sprintf("the directory '%s' doesn't exist, set 'fail_if_missing' to false to read a directory which may be missing", path))
+			return
+		}
+		config.Exists = types.BoolValue(false)
+		config.Permissions = types.StringNull()
+		config.Owner = types.StringNull()
+		config.Group = types.StringNull()
+		config.Xattrs = types.MapNull(types.StringType)
+		config.SelinuxContext = types.StringNull()
+		config.Files = nil
+		resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
 		return
 	}
 	if err != nil {
@@ -129,20 +215,35 @@ func (r *LocalDirectoryDataSource) Read(ctx context.Context, req datasource.Read
 		return
 	}
 
-	if id == "" {
-		hasher := sha256.New()
-		hasher.Write([]byte(path))
-		id = hex.EncodeToString(hasher.Sum(nil))
-		config.ID = types.StringValue(id)
-	}
+	config.Exists = types.BoolValue(true)
 	config.Permissions = types.StringValue(perm)
 
+	info, err := r.client.Info(path)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading directory: ", err.Error())
+		return
+	}
+	config.Owner = ownership.Value(info["Owner"], info["Uid"])
+	config.Group = ownership.Value(info["Group"], info["Gid"])
+
+	xattrs, err := r.client.Xattrs(path)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading extended attributes: ", err.Error())
+		return
+	}
+	var diags diag.Diagnostics
+	config.Xattrs, diags = xattr.Value(ctx, xattrs)
+	resp.Diagnostics.Append(diags...)
+	config.SelinuxContext = xattr.ContextValue(xattrs)
+
 	fileList := []LocalDirectoryFileInfoModel{}
 	for fileName, fileData := range files {
 		fileInfo := LocalDirectoryFileInfoModel{
 			Name:         types.StringValue(fileName),
 			Size:         types.StringValue(fileData["Size"]),
 			Permissions:  types.StringValue(fileData["Mode"]),
+			Owner:        ownership.Value(fileData["Owner"], fileData["Uid"]),
+			Group:        ownership.Value(fileData["Group"], fileData["Gid"]),
 			LastModified: types.StringValue(fileData["ModTime"]),
 			IsDirectory:  types.StringValue(fileData["IsDir"]),
 		}
@@ -4,10 +4,13 @@ package file_local_directory
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"math/rand"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,9 +68,12 @@ func TestLocalDirectoryDataSourceRead(t *testing.T) {
 				}),
 				// want
 				getReadDataSourceResponse(t, map[string]interface{}{
-					"id":          testDirectoryID,
-					"path":        testDirectoryPath,
-					"permissions": defaultDirectoryPerm,
+					"id":              testDirectoryID,
+					"path":            testDirectoryPath,
+					"permissions":     defaultDirectoryPerm,
+					"xattrs":          map[string]interface{}{},
+					"exists":          true,
+					"fail_if_missing": true,
 					"files": []interface{}{
 						map[string]interface{}{
 							"name":          filepath.Join(testDirectoryPath, "test_file_a"),
@@ -178,6 +184,84 @@ func TestLocalDirectoryDataSourceRead(t *testing.T) {
 
 //* Helpers *//
 
+func TestLocalDirectoryDataSourceMissing(t *testing.T) {
+	// the os client returns the error from the os package, the memory client returns its own
+	missingPath := filepath.Join(t.TempDir(), "missing")
+	missingID := sha256.Sum256([]byte(missingPath))
+	testCases := []struct {
+		name      string
+		client    c.DirectoryClient
+		have      map[string]interface{}
+		want      map[string]interface{}
+		wantError string
+	}{
+		{
+			"Fail by default",
+			&c.MemoryDirectoryClient{},
+			map[string]interface{}{"path": testDirectoryPath},
+			nil,
+			"the directory '" + testDirectoryPath + "' doesn't exist",
+		},
+		{
+			"Allowed",
+			&c.MemoryDirectoryClient{},
+			map[string]interface{}{"path": testDirectoryPath, "fail_if_missing": false},
+			map[string]interface{}{
+				"id":              testDirectoryID,
+				"path":            testDirectoryPath,
+				"exists":          false,
+				"fail_if_missing": false,
+			},
+			"",
+		},
+		{
+			"Os fail by default",
+			&c.OsDirectoryClient{},
+			map[string]interface{}{"path": missingPath},
+			nil,
+			"the directory '" + missingPath + "' doesn't exist",
+		},
+		{
+			"Os allowed",
+			&c.OsDirectoryClient{},
+			map[string]interface{}{"path": missingPath, "fail_if_missing": false},
+			map[string]interface{}{
+				"id":              hex.EncodeToString(missingID[:]),
+				"path":            missingPath,
+				"exists":          false,
+				"fail_if_missing": false,
+			},
+			"",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fit := LocalDirectoryDataSource{client: tc.client}
+			resp := getReadDataSourceResponseContainer()
+			fit.Read(context.Background(), getReadDataSourceRequest(t, tc.have), &resp)
+			if tc.wantError != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics[0].Detail(), tc.wantError) {
+					t.Errorf("Read() errors are %v; want an error containing %q", resp.Diagnostics, tc.wantError)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Read() errors: %v", resp.Diagnostics)
+			}
+			var want, got LocalDirectoryDataSourceModel
+			if diags := getReadDataSourceResponse(t, tc.want).State.Get(context.Background(), &want); diags.HasError() {
+				t.Fatalf("error getting want state: %v", diags)
+			}
+			if diags := resp.State.Get(context.Background(), &got); diags.HasError() {
+				t.Fatalf("error getting got state: %v", diags)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func getReadDataSourceRequest(t *testing.T, data map[string]interface{}) datasource.ReadRequest {
 	objType := getDataObjectAttributeTypes()
 	val := buildValue(t, objType, data)
@@ -238,6 +322,17 @@ func buildValue(t *testing.T, tfType tftypes.Type, data interface{}) tftypes.Val
 		}
 		return tftypes.NewValue(typ, elemValues)
 
+	case tftypes.Map:
+		dataMap, ok := data.(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected map[string]interface{} for tftypes.Map, got %T", data)
+		}
+		elemValues := make(map[string]tftypes.Value, len(dataMap))
+		for k, v := range dataMap {
+			elemValues[k] = buildValue(t, typ.ElementType, v)
+		}
+		return tftypes.NewValue(typ, elemValues)
+
 	default:
 		// Handle primitive types
 		if tfType.Is(tftypes.String) {
@@ -275,9 +370,15 @@ func buildValue(t *testing.T, tfType tftypes.Type, data interface{}) tftypes.Val
 func getDataObjectAttributeTypes() tftypes.Object {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
-			"id":          tftypes.String,
-			"path":        tftypes.String,
-			"permissions": tftypes.String,
+			"id":              tftypes.String,
+			"path":            tftypes.String,
+			"permissions":     tftypes.String,
+			"owner":           tftypes.String,
+			"group":           tftypes.String,
+			"xattrs":          tftypes.Map{ElementType: tftypes.String},
+			"selinux_context": tftypes.String,
+			"exists":          tftypes.Bool,
+			"fail_if_missing": tftypes.Bool,
 			"files": tftypes.List{
 				ElementType: tftypes.Object{
 					AttributeTypes: map[string]tftypes.Type{
@@ -286,6 +387,8 @@ func getDataObjectAttributeTypes() tftypes.Object {
 						"permissions":   tftypes.String,
 						"last_modified": tftypes.String,
 						"is_directory":  tftypes.String,
+						"owner":         tftypes.String,
+						"group":         tftypes.String,
 					},
 				},
 			},
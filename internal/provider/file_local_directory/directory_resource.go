@@ -9,15 +9,23 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	c "github.com/rancher/terraform-provider-file/internal/provider/directory_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/ownership"
+	"github.com/rancher/terraform-provider-file/internal/provider/permissions"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/xattr"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -25,6 +33,7 @@ import (
 // These will fail at compilation time if the implementation is not satisfied.
 var _ resource.Resource = &LocalDirectoryResource{}
 var _ resource.ResourceWithImportState = &LocalDirectoryResource{}
+var _ resource.ResourceWithModifyPlan = &LocalDirectoryResource{}
 
 func NewLocalDirectoryResource() resource.Resource {
 	return &LocalDirectoryResource{
@@ -33,15 +42,20 @@ func NewLocalDirectoryResource() resource.Resource {
 }
 
 type LocalDirectoryResource struct {
-	client c.DirectoryClient
+	client       c.DirectoryClient
+	providerData *provider_data.ProviderData
 }
 
 // LocalDirectoryResourceModel describes the resource data model.
 type LocalDirectoryResourceModel struct {
-	ID          types.String `tfsdk:"id"`
-	Path        types.String `tfsdk:"path"`
-	Permissions types.String `tfsdk:"permissions"`
-	Created     types.String `tfsdk:"created"`
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	Permissions    types.String `tfsdk:"permissions"`
+	Owner          types.String `tfsdk:"owner"`
+	Group          types.String `tfsdk:"group"`
+	Xattrs         types.Map    `tfsdk:"xattrs"`
+	SelinuxContext types.String `tfsdk:"selinux_context"`
+	Created        types.String `tfsdk:"created"`
 }
 
 func (r *LocalDirectoryResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -62,11 +76,57 @@ func (r *LocalDirectoryResource) Schema(_ context.Context, _ resource.SchemaRequ
 			},
 			"permissions": schema.StringAttribute{
 				MarkdownDescription: "The directory permissions to assign to the directory, defaults to '0700'. " +
+					"Either octal, eg. '0750' or '1777' with the setgid or sticky bits, or symbolic, eg. 'u=rwx,g=rxs,o='. " +
 					"In order to automatically create subdirectories the owner must have execute access, " +
 					"ie. '0600' or less prevents the provider from creating subdirectories.",
 				Optional: true,
 				Computed: true,
 				Default:  stringdefault.StaticString("0700"),
+				Validators: []validator.String{
+					permissions.Validator(),
+				},
+			},
+			"owner": schema.StringAttribute{
+				MarkdownDescription: "The user which owns the directory, as a name or numeric id. " +
+					"Only the directory at 'path' is changed, any parent directories created along the way are owned by the user running Terraform. " +
+					"Defaults to the provider's 'default_owner', when neither is set the directory is owned by the user running Terraform.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "The group which owns the directory, as a name or numeric id. " +
+					"Defaults to the provider's 'default_group', when neither is set the directory has the primary group of the user running Terraform.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"xattrs": schema.MapAttribute{
+				MarkdownDescription: "Extended attributes to give the directory, the keys are the names with their namespace, eg. 'user.owner'. " +
+					"Attributes which are removed from this map are removed from the directory, other attributes are left alone. " +
+					"Use 'selinux_context' for the SELinux context. " +
+					"The filesystem must support extended attributes, setting them on a filesystem which doesn't is an error.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(
+						stringvalidator.LengthAtLeast(1),
+						stringvalidator.NoneOf(xattr.SELinux),
+					),
+				},
+			},
+			"selinux_context": schema.StringAttribute{
+				MarkdownDescription: "The SELinux context to give the directory, eg. 'system_u:object_r:httpd_sys_content_t:s0'. " +
+					"Only the directory at 'path' is labeled, any parent directories created along the way get their default context. " +
+					"When this isn't set the context isn't managed.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
 			},
 			"id": schema.StringAttribute{
 				MarkdownDescription: "Identifier derived from sha256 hash of path. ",
@@ -90,11 +150,55 @@ func (r *LocalDirectoryResource) Schema(_ context.Context, _ resource.SchemaRequ
 }
 
 // Configure the provider for the resource if necessary.
-func (r *LocalDirectoryResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+func (r *LocalDirectoryResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
+	data, ok := req.ProviderData.(*provider_data.ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider_data.ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	r.providerData = data
+	if client, ok := r.client.(*c.OsDirectoryClient); ok {
+		client.Sandbox = data.Sandbox
+	}
+}
+
+// ModifyPlan applies the provider level defaults to arguments which aren't set in the resource configuration.
+func (r *LocalDirectoryResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The plan is null when the resource is being destroyed, there is nothing to modify.
+	if req.Plan.Raw.IsNull() || r.providerData == nil {
+		return
+	}
+
+	var config LocalDirectoryResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Permissions.IsNull() && r.providerData.DefaultDirectoryPermissions != "" {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("permissions"), r.providerData.DefaultDirectoryPermissions)...)
+	}
+	if config.Owner.IsNull() && r.providerData.DefaultOwner != "" {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("owner"), r.providerData.DefaultOwner)...)
+	}
+	if config.Group.IsNull() && r.providerData.DefaultGroup != "" {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("group"), r.providerData.DefaultGroup)...)
+	}
+
+	// Reject paths outside of the sandbox at plan time, before anything is touched.
+	if config.Path.IsUnknown() {
+		return
+	}
+	if err := r.providerData.Sandbox.Check(config.Path.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Path not allowed: ", err.Error())
+	}
 }
 
 // We should:
@@ -127,6 +231,15 @@ func (r *LocalDirectoryResource) Create(ctx context.Context, req resource.Create
 	}
 	plan.Created = types.StringValue(cutPath)
 
+	if err = r.applyOwnership(&plan); err != nil {
+		resp.Diagnostics.AddError("Error setting directory owner: ", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(r.applyXattrs(ctx, plan, types.MapNull(types.StringType))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
 }
@@ -140,7 +253,6 @@ func (r *LocalDirectoryResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 	sPath := state.Path.ValueString()
-	sPerm := state.Permissions.ValueString()
 	sCreated := state.Created.ValueString()
 	sID := state.ID.ValueString()
 
@@ -166,12 +278,22 @@ func (r *LocalDirectoryResource) Read(ctx context.Context, req resource.ReadRequ
 	}
 	tflog.Debug(ctx, fmt.Sprintf("Read data: %#v", data))
 
-	if perm != sPerm {
-		// update the state with the actual mode
-		state.Permissions = types.StringValue(perm)
+	// update the state with the actual mode, permissions which describe the same mode are kept
+	state.Permissions = permissions.StateValue(state.Permissions, perm)
+
+	info, err := r.client.Info(sPath)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading directory: ", err.Error())
+		return
+	}
+	state.Owner = ownership.StateValue(state.Owner, info["Owner"], info["Uid"])
+	state.Group = ownership.StateValue(state.Group, info["Group"], info["Gid"])
+	resp.Diagnostics.Append(r.readXattrs(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// Only update permissions because id, path, and created should never change.
+	// Only update permissions, ownership, and extended attributes because id, path, and created should never change.
 	// The directory resource manages a new directory, it is not meant to pull file information.
 	// To retrieve file information in a directory, use the directory data source.
 
@@ -199,7 +321,7 @@ func (r *LocalDirectoryResource) Update(ctx context.Context, req resource.Update
 	}
 	rPerm := reality.Permissions.ValueString()
 
-	if cPerm != rPerm {
+	if !permissions.Equal(cPerm, rPerm) {
 		// Only update permissions because id, path, and created should never change.
 		err := r.client.Update(cPath, cPerm)
 		if err != nil {
@@ -208,6 +330,15 @@ func (r *LocalDirectoryResource) Update(ctx context.Context, req resource.Update
 		}
 	}
 
+	if err := r.applyOwnership(&config); err != nil {
+		resp.Diagnostics.AddError("Error updating directory owner: ", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(r.applyXattrs(ctx, config, reality.Xattrs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
 	tflog.Debug(ctx, fmt.Sprintf("Response Object: %#v", *resp))
 }
@@ -234,3 +365,51 @@ func (r *LocalDirectoryResource) Delete(ctx context.Context, req resource.Delete
 func (r *LocalDirectoryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// applyOwnership gives the directory to the configured owner and group, then saves the actual owner and group in the model.
+func (r *LocalDirectoryResource) applyOwnership(data *LocalDirectoryResourceModel) error {
+	path := data.Path.ValueString()
+	owner := data.Owner.ValueString() // unknown and null values are empty strings
+	group := data.Group.ValueString()
+	if owner != "" || group != "" {
+		if err := r.client.Chown(path, owner, group); err != nil {
+			return err
+		}
+	}
+	info, err := r.client.Info(path)
+	if err != nil {
+		return err
+	}
+	data.Owner = ownership.StateValue(data.Owner, info["Owner"], info["Uid"])
+	data.Group = ownership.StateValue(data.Group, info["Group"], info["Gid"])
+	return nil
+}
+
+// applyXattrs gives the directory the configured extended attributes and SELinux context.
+// The previous attributes are the ones in state, those which are no longer configured are removed.
+func (r *LocalDirectoryResource) applyXattrs(ctx context.Context, data LocalDirectoryResourceModel, previous types.Map) diag.Diagnostics {
+	set, remove, diags := xattr.Changes(ctx, data.Xattrs, data.SelinuxContext, previous)
+	if diags.HasError() || (len(set) == 0 && len(remove) == 0) {
+		return diags
+	}
+	if err := r.client.SetXattrs(data.Path.ValueString(), set, remove); err != nil {
+		diags.AddError("Error setting extended attributes: ", err.Error())
+	}
+	return diags
+}
+
+// readXattrs updates the managed extended attributes and SELinux context in state, the directory isn't asked when neither is managed.
+func (r *LocalDirectoryResource) readXattrs(ctx context.Context, state *LocalDirectoryResourceModel) diag.Diagnostics {
+	if state.Xattrs.IsNull() && state.SelinuxContext.IsNull() {
+		return nil
+	}
+	var diags diag.Diagnostics
+	actual, err := r.client.Xattrs(state.Path.ValueString())
+	if err != nil {
+		diags.AddError("Error reading extended attributes: ", err.Error())
+		return diags
+	}
+	state.Xattrs, diags = xattr.StateValue(ctx, state.Xattrs, actual)
+	state.SelinuxContext = xattr.SELinuxValue(state.SelinuxContext, actual)
+	return diags
+}
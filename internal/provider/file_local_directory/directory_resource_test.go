@@ -99,6 +99,28 @@ func TestLocalDirectoryResourceCreate(t *testing.T) {
 					"created":     testCreated,
 				}),
 			},
+			{
+				"Owner and group",
+				LocalDirectoryResource{client: &c.MemoryDirectoryClient{}},
+				// have
+				getCreateRequest(t, map[string]string{
+					"path":        testPath,
+					"permissions": defaultPerm,
+					"owner":       "nobody",
+					"group":       "1000",
+					"id":          defaultID,
+					"created":     defaultCreated,
+				}),
+				// want
+				getCreateResponse(t, map[string]string{
+					"path":        testPath,
+					"permissions": defaultPerm,
+					"owner":       "nobody",
+					"group":       "1000",
+					"id":          testID,
+					"created":     testCreated,
+				}),
+			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
@@ -181,6 +203,35 @@ func TestLocalDirectoryResourceRead(t *testing.T) {
 					"permissions": "0777",
 				},
 			},
+			{
+				"Updates owner",
+				LocalDirectoryResource{client: &c.MemoryDirectoryClient{}},
+				// have
+				getReadRequest(t, map[string]string{
+					"id":          testID,
+					"path":        testPath,
+					"created":     testCreated,
+					"permissions": defaultPerm,
+					"owner":       "nobody",
+					"group":       "1000",
+				}),
+				// want
+				getReadResponse(t, map[string]string{
+					"id":          testID,
+					"path":        testPath,
+					"created":     testCreated,
+					"permissions": defaultPerm,
+					"owner":       "1001",
+					"group":       "1000",
+				}),
+				// setup
+				map[string]string{
+					"path":        testPath,
+					"permissions": defaultPerm,
+					"owner":       "1001",
+					"group":       "1000",
+				},
+			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
@@ -189,6 +240,10 @@ func TestLocalDirectoryResourceRead(t *testing.T) {
 					t.Errorf("Error setting up: %v", err)
 					return
 				}
+				if err := tc.fit.client.Chown(tc.setup["path"], tc.setup["owner"], tc.setup["group"]); err != nil {
+					t.Errorf("Error setting up: %v", err)
+					return
+				}
 				defer func() {
 					if err := tc.fit.client.Delete(created); err != nil {
 						t.Errorf("Error tearing down: %v", err)
@@ -277,6 +332,39 @@ func TestLocalDirectoryResourceUpdate(t *testing.T) {
 					"permissions": defaultPerm,
 				},
 			},
+			{
+				"Sets SELinux context",
+				LocalDirectoryResource{client: &c.MemoryDirectoryClient{}},
+				// have
+				getUpdateRequest(t, map[string]map[string]string{
+					"priorState": {
+						"id":          testID,
+						"path":        testPath,
+						"permissions": defaultPerm,
+						"created":     testCreated,
+					},
+					"plan": {
+						"id":              testID,
+						"path":            testPath,
+						"permissions":     defaultPerm,
+						"created":         testCreated,
+						"selinux_context": "system_u:object_r:var_t:s0",
+					},
+				}),
+				// want
+				getUpdateResponse(t, map[string]string{
+					"id":              testID,
+					"path":            testPath,
+					"permissions":     defaultPerm,
+					"created":         testCreated,
+					"selinux_context": "system_u:object_r:var_t:s0",
+				}),
+				// setup
+				map[string]string{
+					"path":        testPath,
+					"permissions": defaultPerm,
+				},
+			},
 		}
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
@@ -310,6 +398,17 @@ func TestLocalDirectoryResourceUpdate(t *testing.T) {
 					t.Errorf("Directory permissions were not updated correctly. Got %q, want %q", permissionsAfterUpdate, plannedPermissions)
 					return
 				}
+				if !plannedState.SelinuxContext.IsNull() {
+					xattrs, err := tc.fit.client.Xattrs(plannedPath)
+					if err != nil {
+						t.Errorf("Failed to get extended attributes for update verification: %s", err)
+						return
+					}
+					if xattrs["security.selinux"] != plannedState.SelinuxContext.ValueString() {
+						t.Errorf("Directory SELinux context was not updated correctly. Got %q, want %q", xattrs["security.selinux"], plannedState.SelinuxContext.ValueString())
+						return
+					}
+				}
 				if diff := cmp.Diff(tc.want, got); diff != "" {
 					t.Errorf("Update() mismatch (-want +got):\n%s", diff)
 					return
@@ -400,7 +499,7 @@ func getCreateRequest(t *testing.T, data map[string]string) resource.CreateReque
 			}
 		}
 	}
-	planValue := tftypes.NewValue(getObjectAttributeTypes(), planMap)
+	planValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), planMap))
 	return resource.CreateRequest{
 		Plan: tfsdk.Plan{
 			Raw:    planValue,
@@ -427,7 +526,7 @@ func getCreateResponse(t *testing.T, data map[string]string) resource.CreateResp
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.CreateResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -450,7 +549,7 @@ func getReadRequest(t *testing.T, data map[string]string) resource.ReadRequest {
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.ReadRequest{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -477,7 +576,7 @@ func getReadResponse(t *testing.T, data map[string]string) resource.ReadResponse
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.ReadResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -500,7 +599,7 @@ func getUpdateRequest(t *testing.T, data map[string]map[string]string) resource.
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	priorStateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	priorStateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 
 	planMap := make(map[string]tftypes.Value)
 	for key, value := range data["plan"] {
@@ -523,7 +622,7 @@ func getUpdateRequest(t *testing.T, data map[string]map[string]string) resource.
 			}
 		}
 	}
-	planValue := tftypes.NewValue(getObjectAttributeTypes(), planMap)
+	planValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), planMap))
 
 	return resource.UpdateRequest{
 		State: tfsdk.State{
@@ -555,7 +654,7 @@ func getUpdateResponse(t *testing.T, data map[string]string) resource.UpdateResp
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.UpdateResponse{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -578,7 +677,7 @@ func getDeleteRequest(t *testing.T, data map[string]string) resource.DeleteReque
 			stateMap[key] = tftypes.NewValue(tftypes.String, value)
 		}
 	}
-	stateValue := tftypes.NewValue(getObjectAttributeTypes(), stateMap)
+	stateValue := tftypes.NewValue(getObjectAttributeTypes(), fillNulls(getObjectAttributeTypes(), stateMap))
 	return resource.DeleteRequest{
 		State: tfsdk.State{
 			Raw:    stateValue,
@@ -599,13 +698,27 @@ func getDeleteResponse() resource.DeleteResponse {
 	}
 }
 
+// fillNulls sets every attribute missing from the values to null, test cases only need to list the attributes they use.
+func fillNulls(objectType tftypes.Object, values map[string]tftypes.Value) map[string]tftypes.Value {
+	for key, attributeType := range objectType.AttributeTypes {
+		if _, ok := values[key]; !ok {
+			values[key] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return values
+}
+
 func getObjectAttributeTypes() tftypes.Object {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"permissions": tftypes.String,
-			"created":     tftypes.String,
-			"id":          tftypes.String,
+			"path":            tftypes.String,
+			"permissions":     tftypes.String,
+			"owner":           tftypes.String,
+			"group":           tftypes.String,
+			"xattrs":          tftypes.Map{ElementType: tftypes.String},
+			"selinux_context": tftypes.String,
+			"created":         tftypes.String,
+			"id":              tftypes.String,
 		},
 	}
 }
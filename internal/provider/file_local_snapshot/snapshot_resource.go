@@ -2,11 +2,11 @@ package file_local_snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
-	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	c "github.com/rancher/terraform-provider-file/internal/provider/file_client"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -25,6 +26,7 @@ import (
 // These will fail at compilation time if the implementation is not satisfied.
 var _ resource.Resource = &LocalSnapshotResource{}
 var _ resource.ResourceWithImportState = &LocalSnapshotResource{}
+var _ resource.ResourceWithModifyPlan = &LocalSnapshotResource{}
 
 func NewLocalSnapshotResource() resource.Resource {
 	return &LocalSnapshotResource{
@@ -33,7 +35,8 @@ func NewLocalSnapshotResource() resource.Resource {
 }
 
 type LocalSnapshotResource struct {
-	client c.FileClient
+	client       c.FileClient
+	providerData *provider_data.ProviderData
 }
 
 // LocalSnapshotResourceModel describes the resource data model.
@@ -105,13 +108,61 @@ func (r *LocalSnapshotResource) Schema(_ context.Context, _ resource.SchemaReque
 	}
 }
 
-func (r *LocalSnapshotResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+func (r *LocalSnapshotResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	// This only configures the provider, so anything here must be available in the provider package to configure.
 	// If you want to configure a client, do that in the Create/Read/Update/Delete functions.
 	if req.ProviderData == nil {
 		return
 	}
+	data, ok := req.ProviderData.(*provider_data.ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider_data.ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+	r.providerData = data
+	if client, ok := r.client.(*c.OsFileClient); ok {
+		client.Sandbox = data.Sandbox
+	}
+}
+
+// ModifyPlan applies the provider level defaults to arguments which aren't set in the resource configuration.
+func (r *LocalSnapshotResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The plan is null when the resource is being destroyed, there is nothing to modify.
+	if req.Plan.Raw.IsNull() || r.providerData == nil {
+		return
+	}
+
+	var config LocalSnapshotResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Directory.IsNull() && r.providerData.RootDirectory != "" {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("directory"), r.providerData.RootDirectory)...)
+		// The directory plan modifiers ran against the static default, moving the file isn't supported.
+		if !req.State.Raw.IsNull() {
+			var state LocalSnapshotResourceModel
+			resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+			if state.Directory.ValueString() != r.providerData.RootDirectory {
+				resp.RequiresReplace = append(resp.RequiresReplace, path.Root("directory"))
+			}
+		}
+	}
+
+	// Reject paths outside of the sandbox at plan time, before anything is touched.
+	var plan LocalSnapshotResourceModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.Directory.IsUnknown() || plan.Name.IsUnknown() {
+		return
+	}
+	if err := r.providerData.Sandbox.Check(filepath.Join(plan.Directory.ValueString(), plan.Name.ValueString())); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Path not allowed: ", err.Error())
+	}
 }
 
 // We should:
@@ -132,16 +183,20 @@ func (r *LocalSnapshotResource) Create(ctx context.Context, req resource.CreateR
 	pDir := plan.Directory.ValueString()
 	pCompress := plan.Compress.ValueBool()
 
-	tempDir := filepath.Join(os.TempDir(), "terraform-provider-file", "snapshot", uuid.New().String())
-	err := os.MkdirAll(tempDir, os.ModePerm)
+	tempDir, err := scratchDirectory()
 	if err != nil {
 		resp.Diagnostics.AddError("Error creating temporary directory: ", err.Error())
 		return
 	}
 	defer os.RemoveAll(tempDir)
+	client, err := r.scratchClient(tempDir)
+	if err != nil {
+		resp.Diagnostics.AddError("Error creating temporary directory: ", err.Error())
+		return
+	}
 
 	// copy the file to a temporary directory to prevent issues with encoding and compressing large files
-	err = r.client.Copy(filepath.Join(pDir, pName), filepath.Join(tempDir, pName))
+	err = client.Copy(filepath.Join(pDir, pName), filepath.Join(tempDir, pName))
 	if err != nil {
 		resp.Diagnostics.AddError("Error copying file to temporary directory: ", err.Error())
 		return
@@ -149,7 +204,7 @@ func (r *LocalSnapshotResource) Create(ctx context.Context, req resource.CreateR
 
 	name := pName
 	if pCompress {
-		err := r.client.Compress(tempDir, pName, "compressed_"+pName)
+		err := client.Compress(tempDir, pName, "compressed_"+pName)
 		if err != nil {
 			resp.Diagnostics.AddError("Error compressing file: ", err.Error())
 			return
@@ -157,19 +212,19 @@ func (r *LocalSnapshotResource) Create(ctx context.Context, req resource.CreateR
 		name = "compressed_" + pName
 	}
 
-	err = r.client.Encode(tempDir, name, "encoded_"+pName)
+	err = client.Encode(tempDir, name, "encoded_"+pName)
 	if err != nil {
 		resp.Diagnostics.AddError("Error encoding file: ", err.Error())
 		return
 	}
-	_, encodedContents, err := r.client.Read(tempDir, "encoded_"+pName)
+	_, encodedContents, err := client.Read(tempDir, "encoded_"+pName)
 	if err != nil {
 		resp.Diagnostics.AddError("Error reading encoded file: ", err.Error())
 		return
 	}
 	plan.LocalSnapshot = types.StringValue(encodedContents)
 
-	hash, err := r.client.Hash(tempDir, name)
+	hash, err := client.Hash(tempDir, name)
 	if err != nil {
 		resp.Diagnostics.AddError("Error hashing file: ", err.Error())
 		return
@@ -297,3 +352,36 @@ func (r *LocalSnapshotResource) Delete(ctx context.Context, req resource.DeleteR
 func (r *LocalSnapshotResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// scratchDirectory creates a private temporary directory for the snapshot, only this process can use it.
+// The sandbox allows it, so it is checked to be the directory which was created rather than a symlink to somewhere else.
+func scratchDirectory() (string, error) {
+	directory, err := os.MkdirTemp("", "terraform-provider-file-snapshot-")
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(directory)
+	if err != nil {
+		return "", errors.Join(err, os.RemoveAll(directory))
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		// don't remove it, it isn't the directory this process created
+		return "", fmt.Errorf("the temporary directory '%s' isn't a private directory, its mode is %s", directory, info.Mode())
+	}
+	return directory, nil
+}
+
+// scratchClient returns a client which is also allowed to use the temporary directory, the provider's sandbox may not include it.
+func (r *LocalSnapshotResource) scratchClient(directory string) (c.FileClient, error) {
+	client, ok := r.client.(*c.OsFileClient)
+	if !ok {
+		return r.client, nil
+	}
+	s, err := client.Sandbox.Allow(directory)
+	if err != nil {
+		return nil, err
+	}
+	scratch := *client
+	scratch.Sandbox = s
+	return &scratch, nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// The lock modes, both lock a sibling '.lock' file, 'flock' locks it with flock and 'lockfile' creates it.
+const (
+	Flock    = "flock"
+	Lockfile = "lockfile"
+)
+
+// DefaultTimeout is how long to wait for a lock when the timeout isn't given.
+const DefaultTimeout = 30 * time.Second
+
+// retryInterval is how long to wait between attempts, locks are never waited on in the kernel so the timeout is kept.
+const retryInterval = 100 * time.Millisecond
+
+// HeldError is returned when another process still holds the lock after the timeout.
+type HeldError struct {
+	Path    string
+	Holder  string // empty when the holder can't be found
+	Timeout time.Duration
+}
+
+func (e *HeldError) Error() string {
+	holder := e.Holder
+	if holder == "" {
+		holder = "another process"
+	}
+	return fmt.Sprintf("timed out after %s waiting for the lock on '%s', it is held by %s", e.Timeout, e.Path, holder)
+}
+
+// Acquire takes an exclusive advisory lock for the file at path, waiting up to the timeout for other holders to release it.
+// The returned function releases the lock.
+func Acquire(path string, mode string, timeout time.Duration) (func() error, error) {
+	switch mode {
+	case Flock:
+		return acquireFlock(path, path+".lock", timeout)
+	case Lockfile:
+		return acquireLockfile(path+".lock", timeout)
+	default:
+		return nil, fmt.Errorf("unknown lock mode '%s', expected one of '%s' or '%s'", mode, Flock, Lockfile)
+	}
+}
+
+// acquireLockfile creates the lock file, which only succeeds when it doesn't exist.
+// The lock file records which process holds it, so a process waiting for the lock can say who has it.
+func acquireLockfile(path string, timeout time.Duration) (func() error, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsNotExist(err) {
+			// the directory doesn't exist, so neither does the file
+			return func() error { return nil }, nil
+		}
+		if err == nil {
+			_, err = f.WriteString(holder() + "\n")
+			err = errors.Join(err, f.Close())
+			if err != nil {
+				return nil, errors.Join(err, os.Remove(path))
+			}
+			return func() error { return os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			held := &HeldError{Path: path, Timeout: timeout}
+			if contents, readErr := os.ReadFile(path); readErr == nil && strings.TrimSpace(string(contents)) != "" {
+				held.Holder = fmt.Sprintf("'%s' according to the lock file", strings.TrimSpace(string(contents)))
+			}
+			return nil, held
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// holder describes this process, it is written to lock files.
+func holder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("process %d on %s", os.Getpid(), host)
+}
+
+// ParseTimeout converts a timeout like '30s' or '2m', an empty timeout is the default.
+func ParseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return DefaultTimeout, nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("'%s' is negative", timeout)
+	}
+	return duration, nil
+}
+
+// TimeoutValidator checks timeouts while planning.
+func TimeoutValidator() validator.String {
+	return timeoutValidator{}
+}
+
+type timeoutValidator struct{}
+
+func (v timeoutValidator) Description(_ context.Context) string {
+	return "value must be a duration, eg. '30s' or '2m'"
+}
+
+func (v timeoutValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v timeoutValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := ParseTimeout(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid timeout",
+			fmt.Sprintf("The value '%s' isn't valid, %s: %s", req.ConfigValue.ValueString(), v.Description(ctx), err.Error()))
+	}
+}
@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin || freebsd
+
+package lock
+
+import "os"
+
+// flockHolder can't find the holder of a flock on this platform, there is no /proc/locks.
+func flockHolder(_ *os.File) string {
+	return ""
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux && !darwin && !freebsd
+
+package lock
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// acquireFlock always fails on this platform, the 'lockfile' mode works everywhere.
+func acquireFlock(path string, _ string, _ time.Duration) (func() error, error) {
+	return nil, fmt.Errorf("can't lock '%s', flock isn't available on %s, use the '%s' mode instead", path, runtime.GOOS, Lockfile)
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	testCases := []struct {
+		name string
+		mode string
+	}{
+		{"Flock", Flock},
+		{"Lockfile", Lockfile},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mode == Flock && runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+				t.Skipf("flock isn't available on %s", runtime.GOOS)
+			}
+			path := filepath.Join(t.TempDir(), "locked.txt")
+			if err := os.WriteFile(path, []byte("this is a lock test"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			release, err := Acquire(path, tc.mode, 0)
+			if err != nil {
+				t.Fatalf("Acquire() error: %v", err)
+			}
+			// the provider writes by renaming a new file over the old one, the lock is still held afterwards
+			replacement := filepath.Join(filepath.Dir(path), ".locked.txt.tmp")
+			if err = os.WriteFile(replacement, []byte("this is a replaced lock test"), 0600); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			if err = os.Rename(replacement, path); err != nil {
+				t.Fatalf("Error setting up: %v", err)
+			}
+			_, err = Acquire(path, tc.mode, 2*retryInterval)
+			var held *HeldError
+			if !errors.As(err, &held) {
+				t.Fatalf("Acquire() of a held lock is %v; want a HeldError", err)
+			}
+			if runtime.GOOS == "linux" || tc.mode == Lockfile {
+				if want := fmt.Sprintf("process %d", os.Getpid()); !strings.Contains(held.Holder, want) {
+					t.Errorf("HeldError holder is %q; want it to contain %q", held.Holder, want)
+				}
+			}
+			if err = release(); err != nil {
+				t.Fatalf("release() error: %v", err)
+			}
+			release, err = Acquire(path, tc.mode, 0)
+			if err != nil {
+				t.Fatalf("Acquire() after release error: %v", err)
+			}
+			if err = release(); err != nil {
+				t.Fatalf("release() error: %v", err)
+			}
+			// a flock lock file is kept while the file exists, so every process locks the same one
+			if _, err = os.Stat(path + ".lock"); (err == nil) != (tc.mode == Flock) {
+				t.Errorf("lock file after release: %v", err)
+			}
+
+			// the lock file goes when the lock is released after the file was deleted
+			release, err = Acquire(path, tc.mode, 0)
+			if err != nil {
+				t.Fatalf("Acquire() error: %v", err)
+			}
+			if err = os.Remove(path); err != nil {
+				t.Fatalf("Error removing file: %v", err)
+			}
+			if err = release(); err != nil {
+				t.Fatalf("release() error: %v", err)
+			}
+			if _, err = os.Stat(path + ".lock"); !os.IsNotExist(err) {
+				t.Errorf("lock file was left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestAcquireFlockRemoved(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		t.Skipf("flock isn't available on %s", runtime.GOOS)
+	}
+	path := filepath.Join(t.TempDir(), "removed.txt")
+	if err := os.WriteFile(path, []byte("this is a removed lock test"), 0600); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	release, err := Acquire(path, Flock, 0)
+	if err != nil {
+		t.Fatalf("Acquire() error: %v", err)
+	}
+	waited := make(chan func() error)
+	go func() {
+		waiting, err := Acquire(path, Flock, 10*retryInterval)
+		if err != nil {
+			t.Errorf("Acquire() while waiting error: %v", err)
+		}
+		waited <- waiting
+	}()
+	time.Sleep(2 * retryInterval)
+	// deleting the file removes the lock file on release, the waiter has to lock the lock file which replaces it
+	if err = os.Remove(path); err != nil {
+		t.Fatalf("Error removing file: %v", err)
+	}
+	if err = release(); err != nil {
+		t.Fatalf("release() error: %v", err)
+	}
+	waiting := <-waited
+	if waiting == nil {
+		t.FailNow()
+	}
+	if _, err = Acquire(path, Flock, 0); err == nil {
+		t.Errorf("Acquire() succeeded while the waiter holds the lock")
+	}
+	if err = waiting(); err != nil {
+		t.Fatalf("release() error: %v", err)
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		given     string
+		want      time.Duration
+		wantError bool
+	}{
+		{"Default", "", DefaultTimeout, false},
+		{"Seconds", "5s", 5 * time.Second, false},
+		{"Zero", "0s", 0, false},
+		{"Negative", "-1s", 0, true},
+		{"No unit", "5", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTimeout(tc.given)
+			if (err != nil) != tc.wantError {
+				t.Fatalf("ParseTimeout(%q) error is %v; want error: %t", tc.given, err, tc.wantError)
+			}
+			if got != tc.want {
+				t.Errorf("ParseTimeout(%q) is %v; want %v", tc.given, got, tc.want)
+			}
+		})
+	}
+}
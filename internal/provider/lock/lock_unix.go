@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux || darwin || freebsd
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// acquireFlock locks the lock file with flock, the lock is released when the lock file is closed.
+// The file itself isn't locked, every write replaces it with a new file so the lock would stay on the old one.
+// The lock file is kept while the file exists, so every process locks the same file,
+// it is only removed when the lock is released after the file was deleted.
+// A process waiting on a lock file which was removed finds a different file at the path once it has the lock, so it tries again.
+func acquireFlock(path string, lockPath string, timeout time.Duration) (func() error, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+		if os.IsNotExist(err) {
+			// the directory doesn't exist, so neither does the file
+			return func() error { return nil }, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			if sameFile(f, lockPath) {
+				return releaseFlock(f, path, lockPath), nil
+			}
+			// the lock file was removed while waiting for it
+			_ = f.Close()
+			continue
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, errors.Join(err, f.Close())
+		}
+		if time.Now().After(deadline) {
+			held := &HeldError{Path: lockPath, Holder: flockHolder(f), Timeout: timeout}
+			_ = f.Close() // the lock file is only written by its holder
+			return nil, held
+		}
+		_ = f.Close()
+		time.Sleep(retryInterval)
+	}
+}
+
+// releaseFlock returns the function releasing the lock, the lock file is removed first when the file no longer exists.
+func releaseFlock(f *os.File, path string, lockPath string) func() error {
+	return func() error {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			if err = os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return errors.Join(err, f.Close())
+			}
+		}
+		return f.Close()
+	}
+}
+
+// sameFile reports whether the open file is still the file at the path.
+func sameFile(f *os.File, path string) bool {
+	var open, current unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &open); err != nil {
+		return false
+	}
+	if err := unix.Stat(path, &current); err != nil {
+		return false
+	}
+	return open.Dev == current.Dev && open.Ino == current.Ino
+}
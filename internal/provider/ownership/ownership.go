@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package ownership
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Resolve converts an owner and group, given as names or numeric ids, to a uid and gid.
+// An empty owner or group resolves to -1, which os.Chown treats as "don't change".
+func Resolve(owner string, group string) (int, int, error) {
+	uid, err := resolve(owner, func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	})
+	if err != nil {
+		return -1, -1, fmt.Errorf("unable to resolve owner '%s': %w", owner, err)
+	}
+	gid, err := resolve(group, func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	})
+	if err != nil {
+		return -1, -1, fmt.Errorf("unable to resolve group '%s': %w", group, err)
+	}
+	return uid, gid, nil
+}
+
+// Chown changes the owner and group of the path, -1 leaves the id unchanged.
+// Changing the owner clears the setuid and setgid bits on Linux, so the mode is put back afterwards.
+func Chown(path string, uid int, gid int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if err = os.Chown(path, uid, gid); err != nil {
+		return err
+	}
+	if info.Mode()&(os.ModeSetuid|os.ModeSetgid) == 0 {
+		return nil
+	}
+	return os.Chmod(path, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
+
+// Matches reports whether the owner or group given by the user, as a name or numeric id, describes the actual name or id.
+func Matches(given string, name string, id string) bool {
+	return given == id || (name != "" && given == name)
+}
+
+// Value returns the name of an owner or group, falling back to the numeric id, null when neither is known.
+func Value(name string, id string) types.String {
+	switch {
+	case name != "":
+		return types.StringValue(name)
+	case id != "":
+		return types.StringValue(id)
+	}
+	return types.StringNull()
+}
+
+// StateValue returns the owner or group to save in state after reading a file.
+// The current value is kept when it matches reality, so a name and its id never show up as a difference.
+func StateValue(current types.String, name string, id string) types.String {
+	if !current.IsNull() && !current.IsUnknown() && Matches(current.ValueString(), name, id) {
+		return current
+	}
+	if name == "" && id == "" && !current.IsUnknown() {
+		// the client doesn't know the owner, eg. on Windows
+		return current
+	}
+	return Value(name, id)
+}
+
+func resolve(value string, lookup func(string) (string, error)) (int, error) {
+	if value == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, nil
+	}
+	id, err := lookup(value)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(id)
+}
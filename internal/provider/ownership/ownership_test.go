@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package ownership
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		name      string
+		owner     string
+		group     string
+		wantUID   int
+		wantGID   int
+		wantError bool
+	}{
+		{"Empty", "", "", -1, -1, false},
+		{"Numeric", "1234", "5678", 1234, 5678, false},
+		{"Names", "root", "", 0, -1, false},
+		{"Unknown owner", "this-user-does-not-exist", "", -1, -1, true},
+		{"Unknown group", "", "this-group-does-not-exist", -1, -1, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uid, gid, err := Resolve(tc.owner, tc.group)
+			if (err != nil) != tc.wantError {
+				t.Fatalf("Resolve(%q, %q) error is %v; want error: %t", tc.owner, tc.group, err, tc.wantError)
+			}
+			if uid != tc.wantUID || gid != tc.wantGID {
+				t.Errorf("Resolve(%q, %q) is %d, %d; want %d, %d", tc.owner, tc.group, uid, gid, tc.wantUID, tc.wantGID)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	testCases := []struct {
+		name  string
+		given string
+		want  bool
+	}{
+		{"Name", "root", true},
+		{"Id", "0", true},
+		{"Other", "nobody", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Matches(tc.given, "root", "0"); got != tc.want {
+				t.Errorf("Matches(%q) is %t; want %t", tc.given, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package ownership
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// FromFileInfo returns the "Uid", "Gid", "Owner", and "Group" of a file from its stat data.
+// The names are empty when the ids don't resolve to a user or group.
+func FromFileInfo(info os.FileInfo) map[string]string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return map[string]string{}
+	}
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	owner := map[string]string{
+		"Uid":   uid,
+		"Gid":   gid,
+		"Owner": "",
+		"Group": "",
+	}
+	if u, err := user.LookupId(uid); err == nil {
+		owner["Owner"] = u.Username
+	}
+	if g, err := user.LookupGroupId(gid); err == nil {
+		owner["Group"] = g.Name
+	}
+	return owner
+}
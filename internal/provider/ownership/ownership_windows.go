@@ -0,0 +1,14 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package ownership
+
+import (
+	"os"
+)
+
+// FromFileInfo returns an empty map, Windows files don't have a uid or gid.
+func FromFileInfo(_ os.FileInfo) map[string]string {
+	return map[string]string{}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// The Unix special bits, os.FileMode keeps these outside of the permission bits.
+const (
+	setuid = 04000
+	setgid = 02000
+	sticky = 01000
+)
+
+// Parse converts permissions to a file mode, including the setuid, setgid, and sticky bits.
+// Permissions are either octal, eg. '0644' or '4755', or symbolic, eg. 'u=rw,g=r,o='.
+// Symbolic permissions start from no permissions, so 'u+x' is the same as 'u=x', and no umask is applied.
+func Parse(permissions string) (os.FileMode, error) {
+	if permissions == "" {
+		return 0, fmt.Errorf("permissions are empty")
+	}
+	if strings.Trim(permissions, "01234567") == "" {
+		octal, err := strconv.ParseUint(permissions, 8, 32)
+		if err != nil {
+			return 0, err
+		}
+		if octal > 07777 {
+			return 0, fmt.Errorf("'%s' is more than 07777", permissions)
+		}
+		return fromOctal(uint32(octal)), nil
+	}
+	octal, err := parseSymbolic(permissions)
+	if err != nil {
+		return 0, err
+	}
+	return fromOctal(octal), nil
+}
+
+// Format returns the octal permissions of a file mode, eg. '0644' or '04755'.
+func Format(mode os.FileMode) string {
+	return fmt.Sprintf("%#o", toOctal(mode))
+}
+
+// Equal reports whether two permissions describe the same mode, eg. '0640' and 'u=rw,g=r,o='.
+func Equal(a string, b string) bool {
+	aMode, err := Parse(a)
+	if err != nil {
+		return a == b
+	}
+	bMode, err := Parse(b)
+	return err == nil && aMode == bMode
+}
+
+// StateValue returns the permissions to save in state after reading a file or directory.
+// The current value is kept when it describes the actual mode, so symbolic permissions never show up as a difference.
+func StateValue(current types.String, actual string) types.String {
+	if !current.IsNull() && !current.IsUnknown() && Equal(current.ValueString(), actual) {
+		return current
+	}
+	return types.StringValue(actual)
+}
+
+// Validator checks permissions while planning, rather than waiting for the file to be written.
+func Validator() validator.String {
+	return permissionsValidator{}
+}
+
+type permissionsValidator struct{}
+
+func (v permissionsValidator) Description(_ context.Context) string {
+	return "value must be octal permissions, eg. '0644', or symbolic permissions, eg. 'u=rw,g=r,o='"
+}
+
+func (v permissionsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v permissionsValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := Parse(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid permissions",
+			fmt.Sprintf("The value '%s' isn't valid, %s: %s", req.ConfigValue.ValueString(), v.Description(ctx), err.Error()))
+	}
+}
+
+func fromOctal(octal uint32) os.FileMode {
+	mode := os.FileMode(octal & 0777)
+	if octal&setuid != 0 {
+		mode |= os.ModeSetuid
+	}
+	if octal&setgid != 0 {
+		mode |= os.ModeSetgid
+	}
+	if octal&sticky != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+func toOctal(mode os.FileMode) uint32 {
+	octal := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		octal |= setuid
+	}
+	if mode&os.ModeSetgid != 0 {
+		octal |= setgid
+	}
+	if mode&os.ModeSticky != 0 {
+		octal |= sticky
+	}
+	return octal
+}
+
+// parseSymbolic reads clauses like chmod, eg. 'u=rwx,g+rs,o-w,+t', separated by commas.
+// Each clause is who ('u', 'g', 'o', or 'a', none means 'a') followed by operations ('=', '+', or '-') and their permissions ('r', 'w', 'x', 's', or 't').
+func parseSymbolic(permissions string) (uint32, error) {
+	var octal uint32
+	for _, clause := range strings.Split(permissions, ",") {
+		who := strings.TrimLeft(clause, "ugoa")
+		classes := clause[:len(clause)-len(who)]
+		if classes == "" || strings.Contains(classes, "a") {
+			classes = "ugo"
+		}
+		if who == "" {
+			return 0, fmt.Errorf("clause '%s' has no operation, expected one of '=', '+', or '-'", clause)
+		}
+		for who != "" {
+			op := who[0]
+			if op != '=' && op != '+' && op != '-' {
+				return 0, fmt.Errorf("clause '%s' has an unknown operation '%c', expected one of '=', '+', or '-'", clause, op)
+			}
+			rest := strings.TrimLeft(who[1:], "rwxst")
+			bits, err := symbolicBits(classes, who[1:len(who)-len(rest)])
+			if err != nil {
+				return 0, err
+			}
+			switch op {
+			case '=':
+				octal = octal&^classBits(classes) | bits
+			case '+':
+				octal |= bits
+			case '-':
+				octal &^= bits
+			}
+			who = rest
+		}
+	}
+	return octal, nil
+}
+
+// symbolicBits returns the octal bits of permissions like 'rwx' for the classes like 'ug'.
+func symbolicBits(classes string, perms string) (uint32, error) {
+	var bits uint32
+	for _, class := range classes {
+		shift := map[rune]uint{'u': 6, 'g': 3, 'o': 0}[class]
+		for _, perm := range perms {
+			switch perm {
+			case 'r':
+				bits |= 04 << shift
+			case 'w':
+				bits |= 02 << shift
+			case 'x':
+				bits |= 01 << shift
+			case 's':
+				if class == 'u' {
+					bits |= setuid
+				}
+				if class == 'g' {
+					bits |= setgid
+				}
+			case 't':
+				bits |= sticky
+			default:
+				return 0, fmt.Errorf("unknown permission '%c'", perm)
+			}
+		}
+	}
+	return bits, nil
+}
+
+// classBits returns every bit which '=' replaces for the classes, including their special bits.
+func classBits(classes string) uint32 {
+	var bits uint32
+	for _, class := range classes {
+		switch class {
+		case 'u':
+			bits |= 0700 | setuid
+		case 'g':
+			bits |= 070 | setgid
+		case 'o':
+			bits |= 07 | sticky
+		}
+	}
+	return bits
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package permissions
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name      string
+		given     string
+		want      os.FileMode
+		wantError bool
+	}{
+		{"Octal", "0644", 0644, false},
+		{"Octal without leading zero", "755", 0755, false},
+		{"Setuid", "4755", 0755 | os.ModeSetuid, false},
+		{"Setgid and sticky", "03775", 0775 | os.ModeSetgid | os.ModeSticky, false},
+		{"Symbolic", "u=rw,g=r,o=", 0640, false},
+		{"Symbolic all", "a=rx,u+w", 0755, false},
+		{"Symbolic without who", "=r", 0444, false},
+		{"Symbolic special bits", "u=rwxs,g=rxs,o=rx,+t", 0755 | os.ModeSetuid | os.ModeSetgid | os.ModeSticky, false},
+		{"Symbolic remove", "a=rwx,go-w", 0755, false},
+		{"Too large", "17777", 0, true},
+		{"Not octal", "0x644", 0, true},
+		{"Unknown permission", "u=rwz", 0, true},
+		{"Missing operation", "u", 0, true},
+		{"Empty", "", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.given)
+			if (err != nil) != tc.wantError {
+				t.Fatalf("Parse(%q) error is %v; want error: %t", tc.given, err, tc.wantError)
+			}
+			if got != tc.want {
+				t.Errorf("Parse(%q) is %v; want %v", tc.given, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name string
+		mode os.FileMode
+		want string
+	}{
+		{"Permissions", 0640, "0640"},
+		{"Setuid", 0755 | os.ModeSetuid, "04755"},
+		{"Sticky directory", 0777 | os.ModeSticky | os.ModeDir, "01777"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Format(tc.mode); got != tc.want {
+				t.Errorf("Format(%v) is %q; want %q", tc.mode, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStateValue(t *testing.T) {
+	testCases := []struct {
+		name    string
+		current types.String
+		actual  string
+		want    types.String
+	}{
+		{"Same", types.StringValue("0640"), "0640", types.StringValue("0640")},
+		{"Symbolic", types.StringValue("u=rw,g=r,o="), "0640", types.StringValue("u=rw,g=r,o=")},
+		{"Without leading zero", types.StringValue("640"), "0640", types.StringValue("640")},
+		{"Changed", types.StringValue("u=rw,g=r,o="), "0600", types.StringValue("0600")},
+		{"Unknown", types.StringUnknown(), "0600", types.StringValue("0600")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, StateValue(tc.current, tc.actual)); diff != "" {
+				t.Errorf("StateValue() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
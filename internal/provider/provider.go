@@ -5,13 +5,21 @@ package provider
 import (
 	"context"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rancher/terraform-provider-file/internal/provider/encryption"
 	"github.com/rancher/terraform-provider-file/internal/provider/file_local"
 	"github.com/rancher/terraform-provider-file/internal/provider/file_local_directory"
 	"github.com/rancher/terraform-provider-file/internal/provider/file_local_snapshot"
+	"github.com/rancher/terraform-provider-file/internal/provider/permissions"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
 )
 
 // The `var _` is a special Go construct that results in an unusable variable.
@@ -26,7 +34,19 @@ type FileProvider struct {
 	version string
 }
 
-type FileProviderModel struct{}
+type FileProviderModel struct {
+	RootDirectory               types.String `tfsdk:"root_directory"`
+	DefaultFilePermissions      types.String `tfsdk:"default_file_permissions"`
+	DefaultDirectoryPermissions types.String `tfsdk:"default_directory_permissions"`
+	DefaultOwner                types.String `tfsdk:"default_owner"`
+	DefaultGroup                types.String `tfsdk:"default_group"`
+	HmacSecretKey               types.String `tfsdk:"hmac_secret_key"`
+	EncryptionKey               types.String `tfsdk:"encryption_key"`
+	AgeIdentity                 types.String `tfsdk:"age_identity"`
+	AllowedPaths                types.List   `tfsdk:"allowed_paths"`
+	DeniedPaths                 types.List   `tfsdk:"denied_paths"`
+	Durability                  types.String `tfsdk:"durability"`
+}
 
 func (p *FileProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "file"
@@ -35,7 +55,91 @@ func (p *FileProvider) Metadata(_ context.Context, _ provider.MetadataRequest, r
 
 func (p *FileProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Attributes: map[string]schema.Attribute{},
+		MarkdownDescription: "Provider level defaults which are shared with every resource and data source. " +
+			"Any argument set on a resource or data source overrides the matching default configured here.",
+
+		Attributes: map[string]schema.Attribute{
+			"root_directory": schema.StringAttribute{
+				MarkdownDescription: "The directory used when a resource or data source doesn't set its 'directory' argument, " +
+					"defaults to the current working directory.",
+				Optional: true,
+			},
+			"default_file_permissions": schema.StringAttribute{
+				MarkdownDescription: "The permissions assigned to files which don't set their own 'permissions' argument, eg. '0640' or 'u=rw,g=r,o='. " +
+					"When left empty the resource default is used.",
+				Optional: true,
+				Validators: []validator.String{
+					permissions.Validator(),
+				},
+			},
+			"default_directory_permissions": schema.StringAttribute{
+				MarkdownDescription: "The permissions assigned to directories which don't set their own 'permissions' argument, eg. '0750' or 'u=rwx,g=rx,o='. " +
+					"When left empty the resource default is used.",
+				Optional: true,
+				Validators: []validator.String{
+					permissions.Validator(),
+				},
+			},
+			"default_owner": schema.StringAttribute{
+				MarkdownDescription: "The user name or numeric id which should own files and directories that don't set their own owner.",
+				Optional:            true,
+			},
+			"default_group": schema.StringAttribute{
+				MarkdownDescription: "The group name or numeric id which should own files and directories that don't set their own group.",
+				Optional:            true,
+			},
+			"hmac_secret_key": schema.StringAttribute{
+				MarkdownDescription: "The secret key used to calculate the id of protected files which don't set their own 'hmac_secret_key'. " +
+					"This takes precedence over the `TF_FILE_HMAC_SECRET_KEY` environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"encryption_key": schema.StringAttribute{
+				MarkdownDescription: "The base64 encoded AES-256 key used by encryption blocks which don't set their own 'key', eg. from 'openssl rand -base64 32'. " +
+					"This takes precedence over the `TF_FILE_ENCRYPTION_KEY` environment variable.",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					encryption.KeyValidator(),
+				},
+			},
+			"age_identity": schema.StringAttribute{
+				MarkdownDescription: "The age identity used to decrypt files encrypted for age recipients, when the encryption block doesn't set its own 'identity'. " +
+					"This takes precedence over the `TF_FILE_AGE_IDENTITY` environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"allowed_paths": schema.ListAttribute{
+				MarkdownDescription: "A list of directories which resources and data sources are allowed to read, write, and delete. " +
+					"Paths are compared after resolving '..' elements and symlinks, anything outside of these directories is rejected. " +
+					"When left empty every path which isn't denied is allowed. " +
+					"Missing parent directories are only created inside the allowed paths, a parent outside of them is an error before anything is created. " +
+					"The one exception is the file_local_snapshot resource, it copies the file into a private temporary directory " +
+					"which it creates with '0700' permissions in the system temporary directory and removes again, " +
+					"only that directory is allowed for the copy, it is still rejected if it is inside 'denied_paths'.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"denied_paths": schema.ListAttribute{
+				MarkdownDescription: "A list of directories which resources and data sources must never read, write, or delete. " +
+					"Paths are compared after resolving '..' elements and symlinks. " +
+					"Denied paths take precedence over allowed paths.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"durability": schema.StringAttribute{
+				MarkdownDescription: "How hard the provider works to make file writes survive a crash, defaults to 'file'. " +
+					"Files are always written to a temporary file in the same directory, synced, then renamed over the target. " +
+					"The owner and extended attributes of the target, including POSIX ACLs and the SELinux context, are copied to the temporary file first, " +
+					"as far as the user running Terraform is allowed to set them. " +
+					"When set to 'directory' the parent directory is also synced after the rename, " +
+					"this is slower but guarantees the new file is visible after a power loss.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("file", "directory"),
+				},
+			},
+		},
 	}
 }
 
@@ -47,6 +151,58 @@ func (p *FileProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	defaults := map[string]types.String{
+		"default_file_permissions":      data.DefaultFilePermissions,
+		"default_directory_permissions": data.DefaultDirectoryPermissions,
+	}
+	for attribute, value := range defaults {
+		if value.ValueString() == "" {
+			continue
+		}
+		if _, err := permissions.Parse(value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attribute),
+				"Invalid permissions",
+				"The value '"+value.ValueString()+"' isn't a valid file mode, eg. '0600' or 'u=rw,g=,o=': "+err.Error(),
+			)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var allowedPaths []string
+	resp.Diagnostics.Append(data.AllowedPaths.ElementsAs(ctx, &allowedPaths, false)...)
+	var deniedPaths []string
+	resp.Diagnostics.Append(data.DeniedPaths.ElementsAs(ctx, &deniedPaths, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var pathSandbox *sandbox.Sandbox
+	if len(allowedPaths) > 0 || len(deniedPaths) > 0 {
+		s, err := sandbox.New(allowedPaths, deniedPaths)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid path sandbox", err.Error())
+			return
+		}
+		pathSandbox = s
+	}
+
+	providerData := &provider_data.ProviderData{
+		RootDirectory:               data.RootDirectory.ValueString(),
+		DefaultFilePermissions:      data.DefaultFilePermissions.ValueString(),
+		DefaultDirectoryPermissions: data.DefaultDirectoryPermissions.ValueString(),
+		DefaultOwner:                data.DefaultOwner.ValueString(),
+		DefaultGroup:                data.DefaultGroup.ValueString(),
+		HmacSecretKey:               data.HmacSecretKey.ValueString(),
+		EncryptionKey:               data.EncryptionKey.ValueString(),
+		AgeIdentity:                 data.AgeIdentity.ValueString(),
+		Sandbox:                     pathSandbox,
+		SyncDirectory:               data.Durability.ValueString() == "directory",
+	}
+	resp.ResourceData = providerData
+	resp.DataSourceData = providerData
 }
 
 func (p *FileProvider) Resources(_ context.Context) []func() resource.Resource {
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package provider_data
+
+import (
+	"github.com/rancher/terraform-provider-file/internal/provider/sandbox"
+)
+
+// ProviderData holds the provider level configuration.
+// The provider passes a pointer to this struct to every resource and data source in their Configure functions.
+// Resource level arguments always override the values found here.
+type ProviderData struct {
+	RootDirectory               string
+	DefaultFilePermissions      string
+	DefaultDirectoryPermissions string
+	DefaultOwner                string
+	DefaultGroup                string
+	HmacSecretKey               string
+	EncryptionKey               string
+	AgeIdentity                 string
+	// Sandbox is nil when no allowed or denied paths are configured.
+	Sandbox *sandbox.Sandbox
+	// SyncDirectory is true when durability is set to "directory".
+	SyncDirectory bool
+}
@@ -6,7 +6,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/rancher/terraform-provider-file/internal/provider/provider_data"
 )
 
 func TestProviderMetadata(t *testing.T) {
@@ -30,3 +34,125 @@ func TestProviderMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderConfigure(t *testing.T) {
+	testCases := []struct {
+		name      string
+		fit       FileProvider
+		have      map[string]string
+		want      *provider_data.ProviderData
+		wantError bool
+		// The sandbox can't be compared directly, only its existence is checked.
+		wantSandbox bool
+	}{
+		{
+			"Empty configuration",
+			FileProvider{version: "test"},
+			map[string]string{},
+			&provider_data.ProviderData{},
+			false,
+			false,
+		},
+		{
+			"Full configuration",
+			FileProvider{version: "test"},
+			map[string]string{
+				"root_directory":                "/tmp/root",
+				"default_file_permissions":      "0640",
+				"default_directory_permissions": "0750",
+				"default_owner":                 "1000",
+				"default_group":                 "wheel",
+				"hmac_secret_key":               "this-is-a-test-key",
+				"encryption_key":                "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+				"age_identity":                  "AGE-SECRET-KEY-1TEST",
+				"durability":                    "directory",
+			},
+			&provider_data.ProviderData{
+				RootDirectory:               "/tmp/root",
+				DefaultFilePermissions:      "0640",
+				DefaultDirectoryPermissions: "0750",
+				DefaultOwner:                "1000",
+				DefaultGroup:                "wheel",
+				HmacSecretKey:               "this-is-a-test-key",
+				EncryptionKey:               "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+				AgeIdentity:                 "AGE-SECRET-KEY-1TEST",
+				SyncDirectory:               true,
+			},
+			false,
+			false,
+		},
+		{
+			"Sandbox",
+			FileProvider{version: "test"},
+			map[string]string{
+				"allowed_paths": "/tmp",
+			},
+			&provider_data.ProviderData{},
+			false,
+			true,
+		},
+		{
+			"Invalid permissions",
+			FileProvider{version: "test"},
+			map[string]string{
+				"default_file_permissions": "rw-r--r--",
+			},
+			nil,
+			true,
+			false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			schemaResponse := provider.SchemaResponse{}
+			tc.fit.Schema(ctx, provider.SchemaRequest{}, &schemaResponse)
+
+			attributeTypes := map[string]tftypes.Type{}
+			values := map[string]tftypes.Value{}
+			for attribute := range schemaResponse.Schema.Attributes {
+				attributeType := schemaResponse.Schema.Attributes[attribute].GetType().TerraformType(ctx)
+				attributeTypes[attribute] = attributeType
+				value, ok := tc.have[attribute]
+				switch {
+				case !ok:
+					values[attribute] = tftypes.NewValue(attributeType, nil)
+				case attributeType.Is(tftypes.List{}):
+					values[attribute] = tftypes.NewValue(attributeType, []tftypes.Value{tftypes.NewValue(tftypes.String, value)})
+				default:
+					values[attribute] = tftypes.NewValue(tftypes.String, value)
+				}
+			}
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Raw:    tftypes.NewValue(tftypes.Object{AttributeTypes: attributeTypes}, values),
+					Schema: schemaResponse.Schema,
+				},
+			}
+			res := provider.ConfigureResponse{}
+			tc.fit.Configure(ctx, req, &res)
+			if res.Diagnostics.HasError() != tc.wantError {
+				t.Errorf("Configure() errors: %v; want error: %t", res.Diagnostics, tc.wantError)
+				return
+			}
+			if tc.wantError {
+				return
+			}
+			data, ok := res.ResourceData.(*provider_data.ProviderData)
+			if !ok {
+				t.Errorf("Configure() resource data is %T; want *provider_data.ProviderData", res.ResourceData)
+				return
+			}
+			if (data.Sandbox != nil) != tc.wantSandbox {
+				t.Errorf("Configure() sandbox is %v; want sandbox: %t", data.Sandbox, tc.wantSandbox)
+			}
+			data.Sandbox = nil
+			if diff := cmp.Diff(tc.want, res.ResourceData); diff != "" {
+				t.Errorf("Configure() resource data mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, res.DataSourceData); diff != "" {
+				t.Errorf("Configure() data source data mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Sandbox restricts the paths the clients are allowed to touch.
+// A nil Sandbox allows every path.
+type Sandbox struct {
+	allowed []string
+	denied  []string
+}
+
+// New resolves the allowed and denied roots and returns a Sandbox enforcing them.
+// When allowed is empty every path not denied is allowed.
+func New(allowed []string, denied []string) (*Sandbox, error) {
+	s := &Sandbox{}
+	for _, p := range allowed {
+		resolved, err := resolve(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve allowed path '%s': %w", p, err)
+		}
+		s.allowed = append(s.allowed, resolved)
+	}
+	for _, p := range denied {
+		resolved, err := resolve(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve denied path '%s': %w", p, err)
+		}
+		s.denied = append(s.denied, resolved)
+	}
+	return s, nil
+}
+
+// Allow returns a copy of the Sandbox which also allows the directory, the Sandbox itself isn't changed.
+// This is for temporary directories the provider creates for itself, the directory is still rejected when it is denied.
+// A Sandbox without allowed paths already allows the directory, so it is returned as it is.
+func (s *Sandbox) Allow(directory string) (*Sandbox, error) {
+	if s == nil || len(s.allowed) == 0 {
+		return s, nil
+	}
+	resolved, err := resolve(directory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve allowed path '%s': %w", directory, err)
+	}
+	return &Sandbox{
+		allowed: append(slices.Clone(s.allowed), resolved),
+		denied:  s.denied,
+	}, nil
+}
+
+// Check returns an error if the path, after resolving '..' elements and symlinks, escapes the sandbox.
+func (s *Sandbox) Check(path string) error {
+	if s == nil {
+		return nil
+	}
+	resolved, err := resolve(path)
+	if err != nil {
+		return fmt.Errorf("unable to resolve path '%s': %w", path, err)
+	}
+	for _, root := range s.denied {
+		if contains(root, resolved) {
+			return fmt.Errorf("path '%s' resolves to '%s' which is inside the denied path '%s'", path, resolved, root)
+		}
+	}
+	if len(s.allowed) == 0 {
+		return nil
+	}
+	for _, root := range s.allowed {
+		if contains(root, resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"path '%s' resolves to '%s' which is outside of the allowed paths ['%s']",
+		path, resolved, strings.Join(s.allowed, "', '"),
+	)
+}
+
+// resolve returns the absolute path with all symlinks evaluated.
+// The path isn't cleaned before evaluation, so '..' elements following a symlink are resolved the same way the kernel does.
+// The path doesn't need to exist, the longest existing ancestor is evaluated and the rest of the path is appended.
+func resolve(path string) (string, error) {
+	existing := path
+	if !filepath.IsAbs(existing) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		existing = cwd + string(filepath.Separator) + existing
+	}
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		i := strings.LastIndex(existing, string(filepath.Separator))
+		if i <= 0 {
+			// Nothing in the path exists, not even the root.
+			return filepath.Clean(existing), nil
+		}
+		missing = append([]string{existing[i+1:]}, missing...)
+		existing = existing[:i]
+	}
+}
+
+// contains reports whether path is root or a descendant of root.
+func contains(root string, path string) bool {
+	if root == path {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
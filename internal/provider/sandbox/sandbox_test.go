@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSandboxCheck(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "allowed")
+	denied := filepath.Join(allowed, "denied")
+	outside := filepath.Join(root, "outside")
+	for _, p := range []string{allowed, denied, outside} {
+		if err := os.MkdirAll(p, 0700); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+	}
+	// a symlink inside the allowed path which points outside of it
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatalf("Error setting up: %v", err)
+	}
+	s, err := New([]string{allowed}, []string{denied})
+	if err != nil {
+		t.Fatalf("Error creating sandbox: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		fit       *Sandbox
+		have      string
+		wantError bool
+	}{
+		{"Nil sandbox", nil, "/etc/passwd", false},
+		{"Allowed file", s, filepath.Join(allowed, "file.txt"), false},
+		{"Allowed missing subdirectory", s, filepath.Join(allowed, "new", "sub", "file.txt"), false},
+		{"Allowed root", s, allowed, false},
+		{"Outside", s, filepath.Join(outside, "file.txt"), true},
+		{"Denied inside allowed", s, filepath.Join(denied, "file.txt"), true},
+		{"Parent traversal", s, filepath.Join(allowed, "..", "outside", "file.txt"), true},
+		{"Unclean parent traversal", s, allowed + "/new/../../outside/file.txt", true},
+		{"Symlink escape", s, filepath.Join(allowed, "escape", "file.txt"), true},
+		{"Symlink traversal", s, allowed + "/escape/../allowed/file.txt", false},
+		{"Sibling with shared prefix", s, allowed + "-sibling", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fit.Check(tc.have)
+			if (err != nil) != tc.wantError {
+				t.Errorf("Check(%q) error is %v; want error: %t", tc.have, err, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestSandboxAllow(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "allowed")
+	scratch := filepath.Join(root, "scratch")
+	deniedScratch := filepath.Join(root, "denied")
+	for _, p := range []string{allowed, scratch, deniedScratch} {
+		if err := os.MkdirAll(p, 0700); err != nil {
+			t.Fatalf("Error setting up: %v", err)
+		}
+	}
+	s, err := New([]string{allowed}, []string{deniedScratch})
+	if err != nil {
+		t.Fatalf("Error creating sandbox: %v", err)
+	}
+	if err = s.Check(filepath.Join(scratch, "file.txt")); err == nil {
+		t.Fatalf("Check() allowed the scratch directory before it was allowed")
+	}
+	withScratch, err := s.Allow(scratch)
+	if err != nil {
+		t.Fatalf("Allow() error: %v", err)
+	}
+	if err = withScratch.Check(filepath.Join(scratch, "file.txt")); err != nil {
+		t.Errorf("Check() of the allowed scratch directory error: %v", err)
+	}
+	if err = s.Check(filepath.Join(scratch, "file.txt")); err == nil {
+		t.Errorf("Allow() changed the original sandbox")
+	}
+	withDenied, err := s.Allow(deniedScratch)
+	if err != nil {
+		t.Fatalf("Allow() error: %v", err)
+	}
+	if err = withDenied.Check(filepath.Join(deniedScratch, "file.txt")); err == nil {
+		t.Errorf("Check() allowed a denied directory")
+	}
+}
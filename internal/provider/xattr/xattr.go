@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package xattr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// SELinux keeps the security context of a file in this extended attribute.
+const SELinux = "security.selinux"
+
+// unsupported describes the error when the filesystem, or the operating system, can't store extended attributes.
+func unsupported(path string, err error) error {
+	return fmt.Errorf("extended attributes aren't supported by the filesystem at '%s': %w", path, err)
+}
+
+// Changes returns the attributes to set and remove, so a file or directory gets the configured attributes and SELinux context.
+// Attributes which were configured before, but aren't anymore, are removed. The SELinux context is never removed, only replaced.
+func Changes(ctx context.Context, configured types.Map, selinux types.String, previous types.Map) (map[string]string, []string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	set := map[string]string{}
+	if !configured.IsNull() && !configured.IsUnknown() {
+		diags.Append(configured.ElementsAs(ctx, &set, false)...)
+	}
+	if !selinux.IsNull() && !selinux.IsUnknown() {
+		set[SELinux] = selinux.ValueString()
+	}
+	before := map[string]string{}
+	if !previous.IsNull() && !previous.IsUnknown() {
+		diags.Append(previous.ElementsAs(ctx, &before, false)...)
+	}
+	remove := []string{}
+	for name := range before {
+		if _, ok := set[name]; !ok {
+			remove = append(remove, name)
+		}
+	}
+	sort.Strings(remove)
+	return set, remove, diags
+}
+
+// StateValue returns the attributes to save in state after reading a file or directory.
+// Only the attributes already in state are managed, a managed attribute which is gone is left out so it shows up as a difference.
+func StateValue(ctx context.Context, current types.Map, actual map[string]string) (types.Map, diag.Diagnostics) {
+	if current.IsNull() || current.IsUnknown() {
+		return current, nil
+	}
+	managed := map[string]string{}
+	diags := current.ElementsAs(ctx, &managed, false)
+	values := map[string]string{}
+	for name := range managed {
+		if value, ok := actual[name]; ok {
+			values[name] = value
+		}
+	}
+	value, moreDiags := types.MapValueFrom(ctx, types.StringType, values)
+	diags.Append(moreDiags...)
+	return value, diags
+}
+
+// SELinuxValue returns the SELinux context to save in state after reading, it is only saved when it's managed.
+func SELinuxValue(current types.String, actual map[string]string) types.String {
+	if current.IsNull() || current.IsUnknown() {
+		return current
+	}
+	return types.StringValue(actual[SELinux])
+}
+
+// ContextValue returns the SELinux context for data sources, null when there isn't one.
+func ContextValue(actual map[string]string) types.String {
+	if label, ok := actual[SELinux]; ok {
+		return types.StringValue(label)
+	}
+	return types.StringNull()
+}
+
+// Value returns every attribute, for data sources.
+func Value(ctx context.Context, actual map[string]string) (types.Map, diag.Diagnostics) {
+	if actual == nil {
+		actual = map[string]string{}
+	}
+	return types.MapValueFrom(ctx, types.StringType, actual)
+}
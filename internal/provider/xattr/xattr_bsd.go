@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin || freebsd
+
+package xattr
+
+import "golang.org/x/sys/unix"
+
+// errNoAttribute is returned for an extended attribute which doesn't exist.
+const errNoAttribute = unix.ENOATTR
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux && !darwin && !freebsd
+
+package xattr
+
+import (
+	"errors"
+	"runtime"
+)
+
+var errPlatform = errors.New("extended attributes aren't available on " + runtime.GOOS)
+
+// List has no extended attributes to return on this platform.
+func List(path string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+// Copy has no extended attributes to copy on this platform.
+func Copy(from string, to string) error {
+	return nil
+}
+
+// Set always fails on this platform.
+func Set(path string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return unsupported(path, errPlatform)
+}
+
+// Remove always fails on this platform.
+func Remove(path string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return unsupported(path, errPlatform)
+}
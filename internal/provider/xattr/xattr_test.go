@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: MPL-2.0
+
+package xattr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestChanges(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured types.Map
+		selinux    types.String
+		previous   types.Map
+		wantSet    map[string]string
+		wantRemove []string
+	}{
+		{
+			"Nothing managed",
+			types.MapNull(types.StringType),
+			types.StringNull(),
+			types.MapNull(types.StringType),
+			map[string]string{},
+			[]string{},
+		},
+		{
+			"Attributes and context",
+			mapValue(map[string]string{"user.owner": "ops"}),
+			types.StringValue("system_u:object_r:etc_t:s0"),
+			types.MapNull(types.StringType),
+			map[string]string{"user.owner": "ops", SELinux: "system_u:object_r:etc_t:s0"},
+			[]string{},
+		},
+		{
+			"Removed attributes",
+			mapValue(map[string]string{"user.owner": "dev"}),
+			types.StringNull(),
+			mapValue(map[string]string{"user.owner": "ops", "user.team": "web", "user.site": "east"}),
+			map[string]string{"user.owner": "dev"},
+			[]string{"user.site", "user.team"},
+		},
+		{
+			"Unmanaged",
+			types.MapNull(types.StringType),
+			types.StringNull(),
+			mapValue(map[string]string{"user.owner": "ops"}),
+			map[string]string{},
+			[]string{"user.owner"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			set, remove, diags := Changes(context.Background(), tc.configured, tc.selinux, tc.previous)
+			if diags.HasError() {
+				t.Fatalf("Changes() returned errors: %v", diags)
+			}
+			if diff := cmp.Diff(tc.wantSet, set); diff != "" {
+				t.Errorf("Changes() set mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantRemove, remove); diff != "" {
+				t.Errorf("Changes() remove mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStateValue(t *testing.T) {
+	testCases := []struct {
+		name    string
+		current types.Map
+		actual  map[string]string
+		want    types.Map
+	}{
+		{"Unmanaged", types.MapNull(types.StringType), map[string]string{"user.owner": "ops"}, types.MapNull(types.StringType)},
+		{"Same", mapValue(map[string]string{"user.owner": "ops"}), map[string]string{"user.owner": "ops", SELinux: "unconfined_u:object_r:user_tmp_t:s0"}, mapValue(map[string]string{"user.owner": "ops"})},
+		{"Changed", mapValue(map[string]string{"user.owner": "ops"}), map[string]string{"user.owner": "dev"}, mapValue(map[string]string{"user.owner": "dev"})},
+		{"Removed", mapValue(map[string]string{"user.owner": "ops"}), map[string]string{}, mapValue(map[string]string{})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, diags := StateValue(context.Background(), tc.current, tc.actual)
+			if diags.HasError() {
+				t.Fatalf("StateValue() returned errors: %v", diags)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("StateValue() is %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSELinuxValue(t *testing.T) {
+	testCases := []struct {
+		name    string
+		current types.String
+		actual  map[string]string
+		want    types.String
+	}{
+		{"Unmanaged", types.StringNull(), map[string]string{SELinux: "system_u:object_r:etc_t:s0"}, types.StringNull()},
+		{"Changed", types.StringValue("system_u:object_r:etc_t:s0"), map[string]string{SELinux: "system_u:object_r:tmp_t:s0"}, types.StringValue("system_u:object_r:tmp_t:s0")},
+		{"Missing", types.StringValue("system_u:object_r:etc_t:s0"), map[string]string{}, types.StringValue("")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, SELinuxValue(tc.current, tc.actual)); diff != "" {
+				t.Errorf("SELinuxValue() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mapValue(values map[string]string) types.Map {
+	elements := make(map[string]attr.Value, len(values))
+	for name, value := range values {
+		elements[name] = types.StringValue(value)
+	}
+	return types.MapValueMust(types.StringType, elements)
+}
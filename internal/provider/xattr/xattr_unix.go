@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux || darwin || freebsd
+
+package xattr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// List returns every extended attribute of the path, values are strings without the trailing NUL which some attributes have.
+// A filesystem which doesn't support extended attributes has none, only setting them is an error.
+func List(path string) (map[string]string, error) {
+	names, err := listNames(path)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, wrap(path, err)
+	}
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := get(path, name)
+		if errors.Is(err, errNoAttribute) {
+			continue // removed since it was listed
+		}
+		if err != nil {
+			return nil, wrap(path, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Set gives the path the extended attributes, other attributes are left alone.
+func Set(path string, values map[string]string) error {
+	for name, value := range values {
+		if err := unix.Setxattr(path, name, []byte(value), 0); err != nil {
+			return wrap(path, fmt.Errorf("setting '%s': %w", name, err))
+		}
+	}
+	return nil
+}
+
+// Remove removes the extended attributes from the path, attributes which are already gone are ignored.
+func Remove(path string, names []string) error {
+	for _, name := range names {
+		err := unix.Removexattr(path, name)
+		if errors.Is(err, errNoAttribute) {
+			continue
+		}
+		if err != nil {
+			return wrap(path, fmt.Errorf("removing '%s': %w", name, err))
+		}
+	}
+	return nil
+}
+
+// Copy gives the path to the extended attributes of the path from, this includes POSIX ACLs and the SELinux context.
+// The values are copied byte for byte. Every attribute which can be set is copied, the errors for the others are returned together,
+// eg. an unprivileged user can't set 'trusted.' attributes.
+func Copy(from string, to string) error {
+	names, err := listNames(from)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return nil
+	}
+	if err != nil {
+		return wrap(from, err)
+	}
+	var errs []error
+	for _, name := range names {
+		value, err := getBytes(from, name)
+		if errors.Is(err, errNoAttribute) {
+			continue // removed since it was listed
+		}
+		if err == nil {
+			err = unix.Setxattr(to, name, value, 0)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("copying '%s': %w", name, err))
+		}
+	}
+	return wrap(to, errors.Join(errs...))
+}
+
+func listNames(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func get(path string, name string) (string, error) {
+	value, err := getBytes(path, name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(value), "\x00"), nil
+}
+
+func getBytes(path string, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+func wrap(path string, err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return unsupported(path, err)
+	}
+	return err
+}